@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/gofrs/uuid"
+	"github.com/subscriptions_api/internal/auth"
+	"github.com/subscriptions_api/internal/repository"
+)
+
+// runAPIKey управляет API ключами:
+//
+//	api apikey create -name NAME [-role user|admin] [-user-id UUID]
+//	api apikey revoke ID
+//
+// Созданный ключ печатается один раз, в БД сохраняется только его хэш
+func runAPIKey(ctx context.Context, repo *repository.PostgresRepository, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: apikey create -name NAME [-role user|admin] [-user-id UUID] | apikey revoke ID")
+	}
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+		name := fs.String("name", "", "название ключа")
+		role := fs.String("role", auth.RoleUser, "роль клиента: user или admin")
+		userID := fs.String("user-id", "", "UUID пользователя, от имени которого действует ключ; обязателен для роли user")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *name == "" {
+			return fmt.Errorf("-name is required")
+		}
+		if !auth.ValidRole(*role) {
+			return fmt.Errorf("wrong -role %q", *role)
+		}
+		if *role == auth.RoleUser && *userID == "" {
+			return fmt.Errorf("-user-id is required for role %s", auth.RoleUser)
+		}
+		owner := uuid.Nil
+		if *userID != "" {
+			var err error
+			if owner, err = uuid.FromString(*userID); err != nil {
+				return fmt.Errorf("wrong -user-id: %w", err)
+			}
+		}
+
+		key, err := auth.GenerateAPIKey()
+		if err != nil {
+			return err
+		}
+		id, err := repo.CreateAPIKey(ctx, *name, auth.HashAPIKey(key), *role, owner)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("id: %d\nkey: %s\n", id, key)
+		return nil
+
+	case "revoke":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: apikey revoke ID")
+		}
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("wrong id: %w", err)
+		}
+		return repo.RevokeAPIKey(ctx, id)
+	}
+	return fmt.Errorf("unknown apikey command %q", args[0])
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/subscriptions_api/internal/importer"
+)
+
+// runImport импортирует файл подписок из командной строки:
+//
+//	api import [-format csv|ndjson] [-dry-run] [-report errors.csv] file
+//
+// Итог импорта печатается в stdout в формате JSON
+func runImport(ctx context.Context, store importer.Store, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", "", "формат файла: csv или ndjson (по умолчанию по расширению файла)")
+	dryRun := fs.Bool("dry-run", false, "только провалидировать файл, ничего не записывая в БД")
+	reportPath := fs.String("report", "", "путь для отчета об ошибках в формате CSV")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: import [-format csv|ndjson] [-dry-run] [-report errors.csv] file")
+	}
+
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	report, err := importer.Run(ctx, store, file, *format, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	if *reportPath != "" {
+		out, err := os.Create(*reportPath)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		if err := importer.WriteErrorsCSV(out, report); err != nil {
+			return err
+		}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
@@ -3,28 +3,41 @@ package main
 // @title subscriptions API
 // @version 1.0
 // @description API для агрегации записей о подписках
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT в формате "Bearer <token>"
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
 
 import (
 	"context"
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/subscriptions_api/handlers"
+	"github.com/subscriptions_api/internal/auth"
 	"github.com/subscriptions_api/internal/config"
 	"github.com/subscriptions_api/internal/logger"
 	"github.com/subscriptions_api/internal/repository"
+	"github.com/subscriptions_api/internal/retention"
 	"github.com/subscriptions_api/routes"
+	"github.com/subscriptions_api/subscriptions"
 )
 
 func main() {
 	cfg := config.MustLoad()
 
-	app := fiber.New(fiber.Config{
-		Prefork: false,
-	})
-
 	logger.Init("text")
 
+	// политика чисел в JSON нужна до разбора первого запроса и файла импорта
+	if err := subscriptions.SetNumberPolicy(subscriptions.NumberPolicy(cfg.Money.JSONNumbers)); err != nil {
+		log.Fatal("[main|money policy] ", err)
+	}
+
 	/*	db := postgres.ConnectDB(cfg)
 		postgres.CreateTables(db)
 		defer db.Close(context.Background())*/
@@ -34,16 +47,81 @@ func main() {
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s",
 		cfg.Storage.Host, cfg.Storage.Port, cfg.Storage.User, cfg.Storage.Password, cfg.Storage.Name)
 
-	db, err := repository.NewPostgresDB(ctx, dsn)
+	pool, err := repository.NewPostgresDB(ctx, dsn, repository.PoolConfig{
+		MaxConns:          cfg.Storage.MaxConns,
+		MinConns:          cfg.Storage.MinConns,
+		MaxConnLifetime:   cfg.Storage.MaxConnLifetime,
+		MaxConnIdleTime:   cfg.Storage.MaxConnIdleTime,
+		HealthCheckPeriod: cfg.Storage.HealthCheckPeriod,
+	})
 	if err != nil {
 		log.Fatal("Failed to init DB", err)
 	}
-	defer db.Close(ctx)
+	defer pool.Close()
 
-	if err := repository.RunMigrations(ctx, db); err != nil {
+	if err := repository.RunMigrations(ctx, pool); err != nil {
 		log.Fatal("migrations", err)
 	}
 
-	routes.InitRoutes(app)
+	repo := repository.NewPostgresRepository(pool, repository.Timeouts{
+		Read:      cfg.Storage.ReadTimeout,
+		Write:     cfg.Storage.WriteTimeout,
+		Aggregate: cfg.Storage.AggregateTimeout,
+		Bulk:      cfg.Storage.BulkTimeout,
+	})
+
+	// подкоманды выполняются без запуска сервера:
+	// import - импорт файла подписок, apikey - выпуск и отзыв API ключей, rates - загрузка курсов валют
+	if len(os.Args) > 1 {
+		var err error
+		// изменения из CLI попадают в журнал от имени cli
+		ctx := auth.WithPrincipal(ctx, auth.System("cli"))
+		switch os.Args[1] {
+		case "import":
+			err = runImport(ctx, repo, os.Args[2:])
+		case "apikey":
+			err = runAPIKey(ctx, repo, os.Args[2:])
+		case "rates":
+			err = runRates(ctx, repo, os.Args[2:])
+		default:
+			err = fmt.Errorf("unknown command %q", os.Args[1])
+		}
+		if err != nil {
+			log.Fatal(os.Args[1], ": ", err)
+		}
+		return
+	}
+
+	var middleware []fiber.Handler
+	if cfg.Auth.Enabled {
+		verifier, err := auth.NewJWTVerifier(auth.JWTConfig{
+			HS256Secret:        cfg.Auth.JWTSecret,
+			RS256PublicKeyFile: cfg.Auth.JWTPublicKeyFile,
+			JWKSFile:           cfg.Auth.JWKSFile,
+			Issuer:             cfg.Auth.JWTIssuer,
+			Audience:           cfg.Auth.JWTAudience,
+			Leeway:             cfg.Auth.JWTLeeway,
+		})
+		if err != nil {
+			log.Fatal("Failed to load JWT keys", err)
+		}
+		middleware = append(middleware, handlers.Authenticate(auth.New(verifier, repo)))
+	} else {
+		logger.L.Warn("authentication is disabled")
+	}
+
+	// фоновая очистка удаленных подписок по сроку хранения
+	if cfg.Retention.Period > 0 && cfg.Retention.Interval > 0 {
+		go retention.Run(auth.WithPrincipal(ctx, auth.System("retention")), repo, cfg.Retention.Period, cfg.Retention.Interval)
+	}
+
+	app := fiber.New(fiber.Config{
+		Prefork:      false,
+		ErrorHandler: handlers.ErrorHandler,
+		BodyLimit:    cfg.Server.BodyLimit,
+		// тела принимаются потоком: импорт читает файл по мере вставки, остальные маршруты ограничивает LimitBody
+		StreamRequestBody: true,
+	})
+	routes.InitRoutes(app, handlers.New(repo), cfg.Server.BodyLimit, middleware...)
 	log.Fatal(app.Listen(cfg.Server.Port))
 }
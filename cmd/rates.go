@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/subscriptions_api/internal/importer"
+	"github.com/subscriptions_api/internal/repository"
+)
+
+// runRates загружает курсы валют из локального файла:
+//
+//	api rates import FILE
+//
+// Файл - CSV с заголовком currency,month,rate; курсы на уже известные месяцы заменяются
+func runRates(ctx context.Context, repo *repository.PostgresRepository, args []string) error {
+	if len(args) != 2 || args[0] != "import" {
+		return fmt.Errorf("usage: rates import FILE")
+	}
+
+	f, err := os.Open(args[1])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rates, err := importer.ReadRates(f)
+	if err != nil {
+		return err
+	}
+	if len(rates) == 0 {
+		return fmt.Errorf("no rates in %s", args[1])
+	}
+	if err := repo.UpsertRates(ctx, rates); err != nil {
+		return err
+	}
+	fmt.Printf("loaded %d rates\n", len(rates))
+	return nil
+}
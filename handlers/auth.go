@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/subscriptions_api/internal/auth"
+)
+
+// HeaderAPIKey - заголовок, в котором передается API ключ
+const HeaderAPIKey = "X-API-Key"
+
+// principalLocal - ключ клиента в c.Locals
+const principalLocal = "principal"
+
+// Authenticate возвращает middleware, которое пропускает только запросы с действующим JWT
+// (Authorization: Bearer <token>) или API ключом (X-API-Key).
+// Аутентифицированный клиент доступен обработчикам через Principal и в c.UserContext()
+func Authenticate(a *auth.Authenticator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal, err := a.Authenticate(c.UserContext(), c.Get(fiber.HeaderAuthorization), c.Get(HeaderAPIKey))
+		if err != nil {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="subscriptions"`)
+			return sendError(c, "failed authentication", err)
+		}
+
+		c.Locals(principalLocal, principal)
+		c.SetUserContext(auth.WithPrincipal(c.UserContext(), principal))
+		return c.Next()
+	}
+}
+
+// Principal возвращает клиента, аутентифицированного middleware Authenticate, или nil
+func Principal(c *fiber.Ctx) *auth.Principal {
+	principal, _ := c.Locals(principalLocal).(*auth.Principal)
+	return principal
+}
+
+// requireAdmin возвращает ошибку 403, если аутентифицированный клиент не администратор.
+// Без аутентификации (AUTH_ENABLED=false) проверка не выполняется
+func requireAdmin(c *fiber.Ctx, message string) error {
+	if principal := Principal(c); principal != nil && !principal.IsAdmin() {
+		return &APIError{Status: fiber.StatusForbidden, Code: CodeForbidden, Message: message, Err: auth.ErrForbidden}
+	}
+	return nil
+}
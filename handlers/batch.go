@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/subscriptions_api/internal/logger"
+	"github.com/subscriptions_api/internal/repository"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+// BatchItemResult описывает результат одной операции пакета
+// @Description Результат операции пакетного запроса
+type BatchItemResult struct {
+	Index          int            `json:"index"`
+	Op             string         `json:"op" example:"create"`
+	Status         int            `json:"status" example:"201"`
+	SubscriptionID int            `json:"subscription_id,omitempty"`
+	Version        int            `json:"version,omitempty"`
+	Error          *ErrorResponse `json:"error,omitempty"`
+}
+
+// BatchResponse описывает результаты пакетного запроса
+// @Description Результаты пакетного запроса
+type BatchResponse struct {
+	Mode      string            `json:"mode" example:"best-effort"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+// successStatus - статус успешно выполненной операции пакета
+var successStatus = map[string]int{
+	subscriptions.BatchOpCreate: fiber.StatusCreated,
+	subscriptions.BatchOpUpdate: fiber.StatusOK,
+	subscriptions.BatchOpDelete: fiber.StatusOK,
+}
+
+// ApplyBatch godoc
+// @Summary Пакетно создать, обновить и удалить записи о подписках
+// @Description Выполняет до 1000 операций create/update/delete за один запрос и возвращает статус каждой операции.
+// @Description В режиме all-or-nothing (по умолчанию) любая ошибка отменяет весь пакет, ответ получает статус первой ошибки.
+// @Description В режиме best-effort успешные операции применяются независимо от остальных, ответ всегда 200.
+// @Description Для update и delete можно передать version - операция выполнится, только если версия записи не изменилась
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param batch body subscriptions.BatchRequest true "Пакет операций"
+// @Success 200 {object} BatchResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 422 {object} BatchResponse "Пакет all-or-nothing отменен, статусы операций в results"
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/subscriptions/batch [post]
+func (h *Handler) ApplyBatch(c *fiber.Ctx) error {
+	var req subscriptions.BatchRequest
+
+	// парсим JSON в структуру пакета
+	if err := c.BodyParser(&req); err != nil {
+		return sendError(c, "failed parse batch", badRequest("Неверный формат данных", "", err))
+	}
+	if err := subscriptions.ValidateBatch(&req); err != nil {
+		return sendError(c, "failed Validation batch", err)
+	}
+	atomic := req.Mode == subscriptions.BatchModeAtomic
+
+	// провалидируем каждую операцию, в БД отправим только прошедшие валидацию
+	itemErrs := make([]error, len(req.Items))
+	valid := make([]*subscriptions.BatchItem, 0, len(req.Items))
+	validIdx := make([]int, 0, len(req.Items))
+	for i := range req.Items {
+		if err := subscriptions.ValidateBatchItem(&req.Items[i]); err != nil {
+			itemErrs[i] = err
+			continue
+		}
+		valid = append(valid, &req.Items[i])
+		validIdx = append(validIdx, i)
+	}
+
+	// в режиме all-or-nothing пакет с невалидными операциями в БД не отправляется
+	rejected := len(valid) < len(req.Items)
+	if len(valid) > 0 && !(atomic && rejected) {
+		dbErrs, err := h.repo.ApplyBatch(c.UserContext(), valid, atomic)
+		if err != nil {
+			return sendError(c, "failed ApplyBatch request", err)
+		}
+		for k, i := range validIdx {
+			itemErrs[i] = dbErrs[k]
+		}
+	}
+
+	resp := BatchResponse{Mode: req.Mode, Results: make([]BatchItemResult, len(req.Items))}
+	status := fiber.StatusOK
+	for i, item := range req.Items {
+		result := BatchItemResult{Index: i, Op: item.Op}
+		switch {
+		case itemErrs[i] == nil && atomic && rejected:
+			// операция валидна, но пакет не выполнялся из-за других операций
+			apiErr := translateError(repository.ErrBatchRolledBack)
+			body := apiErr.Response()
+			result.Status, result.Error = apiErr.Status, &body
+		case itemErrs[i] == nil:
+			result.Status = successStatus[item.Op]
+			result.SubscriptionID, result.Version = item.ID, item.Version
+			resp.Succeeded++
+		default:
+			apiErr := translateError(itemErrs[i])
+			body := apiErr.Response()
+			result.Status, result.Error = apiErr.Status, &body
+			if atomic && apiErr.Code != CodeRolledBack && status == fiber.StatusOK {
+				status = apiErr.Status
+			}
+		}
+		if result.Error != nil {
+			resp.Failed++
+		}
+		resp.Results[i] = result
+	}
+
+	logger.L.Info("success ApplyBatch request", "mode", req.Mode, "succeeded", resp.Succeeded, "failed", resp.Failed)
+	return c.Status(status).JSON(resp)
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/subscriptions_api/internal/repository"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+// missingID - id подписки, которой нет в тестовом хранилище
+const missingID = 404
+
+// batchRepo имитирует ApplyBatch: create получает id и версию 1, update и delete - следующую версию,
+// операции с missingID падают, а в режиме all-or-nothing ошибка отменяет остальные операции
+func batchRepo(calls *int) *stubRepo {
+	return &stubRepo{applyBatch: func(items []*subscriptions.BatchItem, atomic bool) []error {
+		*calls++
+		results := make([]error, len(items))
+		for i, item := range items {
+			if item.ID == missingID {
+				results[i] = repository.ErrSubscriptionDoesNotExist
+				if atomic {
+					for j := range results {
+						if j != i {
+							results[j] = repository.ErrBatchRolledBack
+						}
+					}
+					return results
+				}
+				continue
+			}
+			if item.Op == subscriptions.BatchOpCreate {
+				item.ID = 100 + i
+			}
+			item.Version++
+		}
+		return results
+	}}
+}
+
+// testBatch отправляет пакет в обработчик ApplyBatch и возвращает статус ответа и его тело
+func testBatch(t *testing.T, repo *stubRepo, body string) (int, BatchResponse) {
+	t.Helper()
+	app := fiber.New()
+	app.Post("/", New(repo).ApplyBatch)
+	req := httptest.NewRequest(fiber.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var out BatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp.StatusCode, out
+}
+
+func TestApplyBatch(t *testing.T) {
+	const (
+		create  = `{"op":"create","subscription":{"service_name":"Yandex Plus","price":39900,"start_date":"01-2025"}}`
+		remove  = `{"op":"delete","subscription_id":7,"version":2}`
+		missing = `{"op":"delete","subscription_id":404}`
+		invalid = `{"op":"update","subscription_id":7}`
+	)
+	tests := []struct {
+		name     string
+		mode     string
+		items    []string
+		status   int
+		statuses []int
+		calls    int
+	}{
+		{
+			name: "all-or-nothing success", mode: subscriptions.BatchModeAtomic, items: []string{create, remove},
+			status: fiber.StatusOK, statuses: []int{fiber.StatusCreated, fiber.StatusOK}, calls: 1,
+		},
+		{
+			name: "all-or-nothing db error", mode: subscriptions.BatchModeAtomic, items: []string{create, missing, remove},
+			status:   fiber.StatusNotFound,
+			statuses: []int{fiber.StatusFailedDependency, fiber.StatusNotFound, fiber.StatusFailedDependency}, calls: 1,
+		},
+		{
+			name: "all-or-nothing invalid item", mode: subscriptions.BatchModeAtomic, items: []string{create, invalid},
+			status: fiber.StatusUnprocessableEntity, statuses: []int{fiber.StatusFailedDependency, fiber.StatusUnprocessableEntity},
+		},
+		{
+			name: "best-effort", mode: subscriptions.BatchModeBestEffort, items: []string{create, missing, invalid, remove},
+			status:   fiber.StatusOK,
+			statuses: []int{fiber.StatusCreated, fiber.StatusNotFound, fiber.StatusUnprocessableEntity, fiber.StatusOK}, calls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			body := `{"mode":"` + tt.mode + `","items":[` + strings.Join(tt.items, ",") + `]}`
+			status, resp := testBatch(t, batchRepo(&calls), body)
+			if status != tt.status {
+				t.Errorf("status = %d, want %d", status, tt.status)
+			}
+			if calls != tt.calls {
+				t.Errorf("ApplyBatch calls = %d, want %d", calls, tt.calls)
+			}
+			if len(resp.Results) != len(tt.statuses) {
+				t.Fatalf("results = %d, want %d", len(resp.Results), len(tt.statuses))
+			}
+			succeeded := 0
+			for i, result := range resp.Results {
+				if result.Index != i || result.Status != tt.statuses[i] {
+					t.Errorf("result %d = index %d status %d, want status %d", i, result.Index, result.Status, tt.statuses[i])
+				}
+				if (result.Error == nil) != (result.Status < fiber.StatusBadRequest) {
+					t.Errorf("result %d: status %d with error %v", i, result.Status, result.Error)
+				}
+				if result.Error == nil {
+					succeeded++
+				}
+			}
+			if resp.Succeeded != succeeded || resp.Failed != len(tt.statuses)-succeeded {
+				t.Errorf("succeeded/failed = %d/%d, want %d/%d", resp.Succeeded, resp.Failed, succeeded, len(tt.statuses)-succeeded)
+			}
+		})
+	}
+}
+
+func TestApplyBatchResults(t *testing.T) {
+	calls := 0
+	body := `{"items":[{"op":"create","subscription":{"service_name":"Yandex Plus","price":39900,"start_date":"01-2025"}},` +
+		`{"op":"delete","subscription_id":7,"version":2}]}`
+	status, resp := testBatch(t, batchRepo(&calls), body)
+	if status != fiber.StatusOK || resp.Mode != subscriptions.BatchModeAtomic {
+		t.Fatalf("status = %d, mode = %q", status, resp.Mode)
+	}
+	// удаление возвращает новую версию записи
+	want := []BatchItemResult{
+		{Index: 0, Op: subscriptions.BatchOpCreate, Status: fiber.StatusCreated, SubscriptionID: 100, Version: 1},
+		{Index: 1, Op: subscriptions.BatchOpDelete, Status: fiber.StatusOK, SubscriptionID: 7, Version: 3},
+	}
+	for i, result := range resp.Results {
+		if result != want[i] {
+			t.Errorf("result %d = %+v, want %+v", i, result, want[i])
+		}
+	}
+}
+
+func TestApplyBatchInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "wrong json", body: `{"items":`},
+		{name: "wrong mode", body: `{"mode":"all","items":[{"op":"delete","subscription_id":1}]}`},
+		{name: "empty", body: `{"items":[]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			app := fiber.New()
+			app.Post("/", New(batchRepo(&calls)).ApplyBatch)
+			req := httptest.NewRequest(fiber.MethodPost, "/", strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode < fiber.StatusBadRequest || resp.StatusCode >= fiber.StatusInternalServerError || calls != 0 {
+				t.Errorf("status = %d, ApplyBatch calls = %d", resp.StatusCode, calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/subscriptions_api/internal/auth"
+	"github.com/subscriptions_api/internal/logger"
+	"github.com/subscriptions_api/internal/repository"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+// машиночитаемые коды ошибок
+const (
+	CodeBadRequest         = "bad_request"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeConflict           = "conflict"
+	CodePreconditionFailed = "precondition_failed"
+	CodeValidationFailed   = "validation_failed"
+	CodeRolledBack         = "rolled_back"
+	CodeRequestCanceled    = "request_canceled"
+	CodeTimeout            = "timeout"
+	CodeInternal           = "internal_error"
+)
+
+// StatusClientClosedRequest - нестандартный статус для запросов, отмененных клиентом
+const StatusClientClosedRequest = 499
+
+// ErrorResponse описывает тело ответа с ошибкой
+// @Description Ошибка запроса
+type ErrorResponse struct {
+	Code    string        `json:"code" example:"validation_failed"`
+	Message string        `json:"message" example:"Стоимость не может быть отрицательной"`
+	Details []FieldDetail `json:"details,omitempty"`
+}
+
+// FieldDetail описывает ошибку в конкретном поле запроса
+// @Description Ошибка в поле запроса
+type FieldDetail struct {
+	Field   string `json:"field" example:"price"`
+	Message string `json:"message" example:"Стоимость не может быть отрицательной"`
+}
+
+// APIError - ошибка, уже переведенная в HTTP-статус и тело ответа
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+	Details []FieldDetail
+	Err     error
+}
+
+func (e *APIError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// badRequest описывает синтаксически некорректный запрос (неверный JSON, id, параметр)
+func badRequest(message string, field string, err error) *APIError {
+	apiErr := &APIError{Status: fiber.StatusBadRequest, Code: CodeBadRequest, Message: message, Err: err}
+	if field != "" {
+		apiErr.Details = []FieldDetail{{Field: field, Message: message}}
+	}
+	return apiErr
+}
+
+// validationMessages - сообщения для клиента по ошибкам валидации модели
+var validationMessages = []struct {
+	err     error
+	message string
+}{
+	{subscriptions.ErrWrongPrice, "Стоимость не может быть отрицательной"},
+	{subscriptions.ErrWrongFormatDate, "Неправильно указан формат даты"},
+	{subscriptions.ErrWrongDatesInterval, "Дата окончания не может быть меньше даты начала"},
+	{subscriptions.ErrWrongLimit, "limit должен быть от 1 до 500"},
+	{subscriptions.ErrWrongSortField, "Сортировка возможна по price, start_date или service_name"},
+	{subscriptions.ErrWrongSortOrder, "Направление сортировки должно быть asc или desc"},
+	{subscriptions.ErrWrongPriceRange, "price_min не может быть больше price_max"},
+	{subscriptions.ErrWrongTotalMode, "Режим подсчета должен быть overlap-months, prorated или contained"},
+	{subscriptions.ErrWrongGroupBy, "Группировка возможна по service_name или user_id"},
+	{subscriptions.ErrWrongBreakdownMode, "Помесячная разбивка считается в режиме overlap-months или prorated"},
+	{subscriptions.ErrPeriodTooLong, "Период не может быть длиннее 120 месяцев"},
+	{subscriptions.ErrWrongBatchMode, "Режим пакета должен быть all-or-nothing или best-effort"},
+	{subscriptions.ErrWrongBatchSize, "Пакет должен содержать от 1 до 1000 операций"},
+	{subscriptions.ErrWrongBatchOp, "Операция должна быть create, update или delete"},
+	{subscriptions.ErrWrongID, "Для update и delete нужно указать subscription_id"},
+	{subscriptions.ErrWrongVersion, "Версия не может быть отрицательной"},
+	{subscriptions.ErrMissingSubscription, "Для create и update нужно передать subscription"},
+	{subscriptions.ErrWrongCurrency, "Валюта должна быть трехбуквенным кодом ISO 4217"},
+	{subscriptions.ErrWrongRate, "Курс должен быть положительным числом"},
+	{subscriptions.ErrWrongServiceName, "Название сервиса должно быть непустым и не длиннее 32 символов"},
+	{subscriptions.ErrWrongCategory, "Категория сервиса не может быть длиннее 64 символов"},
+	{subscriptions.ErrWrongMergeSource, "from должен быть id другого сервиса"},
+	{subscriptions.ErrWrongBillingPeriod, "Период списания должен быть weekly, monthly, quarterly, yearly или custom"},
+	{subscriptions.ErrWrongBillingMonths, "billing_months задается только для custom и должен быть от 1 до 120"},
+	{subscriptions.ErrWrongAmount, "Сумма должна быть десятичным числом не более чем с двумя знаками после точки"},
+	{subscriptions.ErrAmountOverflow, "Сумма выходит за допустимый диапазон"},
+	{subscriptions.ErrWrongForecastMonths, "months должен быть от 1 до 36"},
+	{subscriptions.ErrWrongPriceMonth, "Цену можно изменить только с будущего месяца после начала подписки и не позже ее окончания"},
+}
+
+// translateError переводит ошибку доменного слоя или БД в APIError
+func translateError(err error) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	// ошибки валидации модели
+	for _, v := range validationMessages {
+		if errors.Is(err, v.err) {
+			apiErr = &APIError{Status: fiber.StatusUnprocessableEntity, Code: CodeValidationFailed, Message: v.message, Err: err}
+			var fieldErr *subscriptions.FieldError
+			if errors.As(err, &fieldErr) {
+				apiErr.Details = []FieldDetail{{Field: fieldErr.Field, Message: v.message}}
+			}
+			return apiErr
+		}
+	}
+
+	switch {
+	case errors.Is(err, auth.ErrMissingCredentials):
+		return &APIError{Status: fiber.StatusUnauthorized, Code: CodeUnauthorized, Message: "Требуется аутентификация", Err: err}
+	case errors.Is(err, auth.ErrTokenExpired):
+		return &APIError{Status: fiber.StatusUnauthorized, Code: CodeUnauthorized, Message: "Срок действия токена истек", Err: err}
+	case errors.Is(err, auth.ErrInvalidToken), errors.Is(err, auth.ErrInvalidAPIKey):
+		return &APIError{Status: fiber.StatusUnauthorized, Code: CodeUnauthorized, Message: "Неверные учетные данные", Err: err}
+	case errors.Is(err, auth.ErrForbidden):
+		return &APIError{Status: fiber.StatusForbidden, Code: CodeForbidden, Message: "Нет доступа к подпискам другого пользователя", Err: err}
+	case errors.Is(err, repository.ErrSubscriptionDoesNotExist):
+		return &APIError{Status: fiber.StatusNotFound, Code: CodeNotFound, Message: "Запись о подписке не найдена", Err: err}
+	case errors.Is(err, repository.ErrServiceDoesNotExist):
+		return &APIError{Status: fiber.StatusNotFound, Code: CodeNotFound, Message: "Сервис не найден", Err: err}
+	case errors.Is(err, repository.ErrPriceChangeDoesNotExist):
+		return &APIError{Status: fiber.StatusNotFound, Code: CodeNotFound, Message: "Изменение цены на этот месяц не найдено", Err: err}
+	case errors.Is(err, repository.ErrSubscriptionNotDeleted):
+		return &APIError{Status: fiber.StatusConflict, Code: CodeConflict, Message: "Запись о подписке не удалена", Err: err}
+	case errors.Is(err, subscriptions.ErrMissingRate):
+		return &APIError{Status: fiber.StatusUnprocessableEntity, Code: CodeValidationFailed, Message: "Нет курса валюты на один из месяцев периода", Err: err}
+	case errors.Is(err, repository.ErrVersionMismatch):
+		return preconditionFailed(err)
+	case errors.Is(err, repository.ErrBatchRolledBack):
+		return &APIError{Status: fiber.StatusFailedDependency, Code: CodeRolledBack, Message: "Операция отменена из-за ошибки в другой операции пакета", Err: err}
+	case errors.Is(err, repository.ErrAsOfBeforeJournal):
+		message := "as_of раньше начала журнала изменений: состояние подписок на этот момент неизвестно"
+		return &APIError{Status: fiber.StatusUnprocessableEntity, Code: CodeValidationFailed, Message: message,
+			Details: []FieldDetail{{Field: "as_of", Message: message}}, Err: err}
+	case errors.Is(err, repository.ErrInvalidCursor):
+		return badRequest("Некорректный cursor", "cursor", err)
+	case errors.Is(err, context.Canceled):
+		return &APIError{Status: StatusClientClosedRequest, Code: CodeRequestCanceled, Message: "Запрос отменен", Err: err}
+	case errors.Is(err, context.DeadlineExceeded) || pgconn.Timeout(err):
+		return &APIError{Status: fiber.StatusGatewayTimeout, Code: CodeTimeout, Message: "Превышено время ожидания ответа БД", Err: err}
+	}
+
+	// ошибки ограничений БД
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == pgerrcode.UniqueViolation,
+			pgErr.Code == pgerrcode.ForeignKeyViolation,
+			pgErr.Code == pgerrcode.ExclusionViolation:
+			return &APIError{Status: fiber.StatusConflict, Code: CodeConflict, Message: "Запись конфликтует с существующими данными", Details: pgErrorDetails(pgErr), Err: err}
+		case pgErr.Code == pgerrcode.CheckViolation,
+			pgErr.Code == pgerrcode.NotNullViolation,
+			pgerrcode.IsDataException(pgErr.Code):
+			return &APIError{Status: fiber.StatusUnprocessableEntity, Code: CodeValidationFailed, Message: "Данные не прошли проверку БД", Details: pgErrorDetails(pgErr), Err: err}
+		}
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return &APIError{Status: fiberErr.Code, Code: codeForStatus(fiberErr.Code), Message: fiberErr.Message, Err: err}
+	}
+
+	return &APIError{Status: fiber.StatusInternalServerError, Code: CodeInternal, Message: "Внутренняя ошибка сервера", Err: err}
+}
+
+// pgErrorDetails достает из ошибки БД имя колонки, если Postgres его сообщил
+func pgErrorDetails(pgErr *pgconn.PgError) []FieldDetail {
+	if pgErr.ColumnName == "" {
+		return nil
+	}
+	return []FieldDetail{{Field: pgErr.ColumnName, Message: pgErr.Message}}
+}
+
+func codeForStatus(status int) string {
+	switch status {
+	case fiber.StatusUnauthorized:
+		return CodeUnauthorized
+	case fiber.StatusForbidden:
+		return CodeForbidden
+	case fiber.StatusNotFound:
+		return CodeNotFound
+	case fiber.StatusConflict:
+		return CodeConflict
+	case fiber.StatusPreconditionFailed:
+		return CodePreconditionFailed
+	case fiber.StatusUnprocessableEntity:
+		return CodeValidationFailed
+	case fiber.StatusGatewayTimeout:
+		return CodeTimeout
+	}
+	if status >= fiber.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// sendError логирует ошибку и отправляет клиенту ответ в едином формате
+func sendError(c *fiber.Ctx, msg string, err error) error {
+	apiErr := translateError(err)
+	switch apiErr.Code {
+	case CodeTimeout:
+		// таймауты логируем отдельной записью, чтобы их можно было отследить по логам
+		logger.L.Error("database query timed out", "op", msg, "method", c.Method(), "path", c.Path(), "error", err)
+	case CodeRequestCanceled:
+		logger.L.Warn("request canceled by client", "op", msg, "method", c.Method(), "path", c.Path(), "error", err)
+	default:
+		logger.L.Error(msg, "status", apiErr.Status, "code", apiErr.Code, "error", err)
+	}
+
+	return c.Status(apiErr.Status).JSON(apiErr.Response())
+}
+
+// Response возвращает тело ответа с ошибкой
+func (e *APIError) Response() ErrorResponse {
+	return ErrorResponse{
+		Code:    e.Code,
+		Message: e.Message,
+		Details: e.Details,
+	}
+}
+
+// ErrorHandler - обработчик ошибок fiber, приводящий их к единому формату ответа
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	return sendError(c, "request failed", err)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/subscriptions_api/internal/auth"
+	"github.com/subscriptions_api/internal/repository"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		field  string
+	}{
+		{
+			name: "validation", status: fiber.StatusUnprocessableEntity, code: CodeValidationFailed, field: "price",
+			err: fmt.Errorf("[Validate|price] %w", &subscriptions.FieldError{Field: "price", Err: subscriptions.ErrWrongPrice}),
+		},
+		{
+			name: "not found", status: fiber.StatusNotFound, code: CodeNotFound,
+			err: fmt.Errorf("[GetSubscriptionById] %w", repository.ErrSubscriptionDoesNotExist),
+		},
+		{
+			name: "not deleted", status: fiber.StatusConflict, code: CodeConflict,
+			err: fmt.Errorf("[RestoreSubscriptionById] %w", repository.ErrSubscriptionNotDeleted),
+		},
+		{
+			name: "version mismatch", status: fiber.StatusPreconditionFailed, code: CodePreconditionFailed,
+			err: fmt.Errorf("[UpdateSubscriptionById] %w", repository.ErrVersionMismatch),
+		},
+		{
+			name: "as_of before journal", status: fiber.StatusUnprocessableEntity, code: CodeValidationFailed, field: "as_of",
+			err: fmt.Errorf("[checkAsOf] %w", repository.ErrAsOfBeforeJournal),
+		},
+		{
+			name: "invalid cursor", status: fiber.StatusBadRequest, code: CodeBadRequest, field: "cursor",
+			err: fmt.Errorf("[GetAllSubscriptions] %w", repository.ErrInvalidCursor),
+		},
+		{
+			name: "expired token", status: fiber.StatusUnauthorized, code: CodeUnauthorized,
+			err: fmt.Errorf("[Authenticate] %w", auth.ErrTokenExpired),
+		},
+		{
+			name: "forbidden", status: fiber.StatusForbidden, code: CodeForbidden,
+			err: fmt.Errorf("[ownerScope|user] %w", auth.ErrForbidden),
+		},
+		{
+			name: "unique violation", status: fiber.StatusConflict, code: CodeConflict,
+			err: fmt.Errorf("[CreateSubscription] %w", &pgconn.PgError{Code: pgerrcode.UniqueViolation}),
+		},
+		{
+			name: "check violation", status: fiber.StatusUnprocessableEntity, code: CodeValidationFailed, field: "price",
+			err: &pgconn.PgError{Code: pgerrcode.CheckViolation, ColumnName: "price"},
+		},
+		{
+			name: "data exception", status: fiber.StatusUnprocessableEntity, code: CodeValidationFailed,
+			err: &pgconn.PgError{Code: pgerrcode.NumericValueOutOfRange},
+		},
+		{
+			name: "canceled", status: StatusClientClosedRequest, code: CodeRequestCanceled,
+			err: fmt.Errorf("[GetAllSubscriptions] %w", context.Canceled),
+		},
+		{
+			name: "deadline", status: fiber.StatusGatewayTimeout, code: CodeTimeout,
+			err: fmt.Errorf("[GetTotalPriceInPeriod] %w", context.DeadlineExceeded),
+		},
+		{
+			name: "fiber error", status: fiber.StatusMethodNotAllowed, code: CodeBadRequest,
+			err: fiber.ErrMethodNotAllowed,
+		},
+		{
+			name: "unknown", status: fiber.StatusInternalServerError, code: CodeInternal,
+			err: errors.New("connection reset"),
+		},
+		{
+			name: "already translated", status: fiber.StatusBadRequest, code: CodeBadRequest, field: "id",
+			err: badRequest("Неверный id", "id", nil),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := translateError(tt.err)
+			if got.Status != tt.status || got.Code != tt.code {
+				t.Fatalf("translateError(%v) = %d %s, want %d %s", tt.err, got.Status, got.Code, tt.status, tt.code)
+			}
+			if !errors.Is(got, tt.err) {
+				t.Errorf("translated error does not wrap %v", tt.err)
+			}
+			field := ""
+			if len(got.Details) > 0 {
+				field = got.Details[0].Field
+			}
+			if field != tt.field {
+				t.Errorf("details field = %q, want %q", field, tt.field)
+			}
+		})
+	}
+}
+
+func TestCodeForStatus(t *testing.T) {
+	tests := map[int]string{
+		fiber.StatusBadRequest:          CodeBadRequest,
+		fiber.StatusUnauthorized:        CodeUnauthorized,
+		fiber.StatusForbidden:           CodeForbidden,
+		fiber.StatusNotFound:            CodeNotFound,
+		fiber.StatusConflict:            CodeConflict,
+		fiber.StatusPreconditionFailed:  CodePreconditionFailed,
+		fiber.StatusUnprocessableEntity: CodeValidationFailed,
+		fiber.StatusServiceUnavailable:  CodeInternal,
+	}
+	for status, want := range tests {
+		if got := codeForStatus(status); got != want {
+			t.Errorf("codeForStatus(%d) = %q, want %q", status, got, want)
+		}
+	}
+}
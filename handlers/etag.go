@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/subscriptions_api/internal/repository"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+// etag формирует ETag подписки по ее версии
+func etag(sub *subscriptions.Subscription) string {
+	return fmt.Sprintf(`"%d"`, sub.Version)
+}
+
+// setETag выставляет заголовок ETag для подписки
+func setETag(c *fiber.Ctx, sub *subscriptions.Subscription) {
+	c.Set(fiber.HeaderETag, etag(sub))
+}
+
+// parseIfMatch разбирает заголовок If-Match в ожидаемую версию подписки.
+// Пустой заголовок и "*" не ограничивают версию
+func parseIfMatch(c *fiber.Ctx) (int, error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" || header == "*" {
+		return repository.AnyVersion, nil
+	}
+	if strings.Contains(header, ",") {
+		return 0, badRequest("If-Match должен содержать один ETag", "If-Match", nil)
+	}
+
+	// If-Match использует сильное сравнение, слабый ETag никогда не совпадает
+	if strings.HasPrefix(header, "W/") {
+		return 0, preconditionFailed(nil)
+	}
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version <= 0 {
+		return 0, badRequest("If-Match должен содержать ETag подписки", "If-Match", err)
+	}
+	return version, nil
+}
+
+// noneMatch сообщает, совпадает ли текущий ETag подписки с заголовком If-None-Match
+func noneMatch(c *fiber.Ctx, sub *subscriptions.Subscription) bool {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfNoneMatch))
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+
+	// If-None-Match использует слабое сравнение
+	current := etag(sub)
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == current {
+			return true
+		}
+	}
+	return false
+}
+
+func preconditionFailed(err error) *APIError {
+	return &APIError{
+		Status:  fiber.StatusPreconditionFailed,
+		Code:    CodePreconditionFailed,
+		Message: "Запись о подписке была изменена, получите актуальную версию",
+		Err:     err,
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/subscriptions_api/internal/repository"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+// testHeaders вызывает fn внутри обработчика запроса с заголовками headers
+func testHeaders(t *testing.T, headers map[string]string, fn func(c *fiber.Ctx)) {
+	t.Helper()
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		fn(c)
+		return nil
+	})
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if _, err := app.Test(req); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header  string
+		version int
+		status  int
+	}{
+		{header: "", version: repository.AnyVersion},
+		{header: "*", version: repository.AnyVersion},
+		{header: `"3"`, version: 3},
+		{header: ` "12" `, version: 12},
+		{header: `W/"3"`, status: fiber.StatusPreconditionFailed},
+		{header: `"abc"`, status: fiber.StatusBadRequest},
+		{header: `"0"`, status: fiber.StatusBadRequest},
+		{header: `"3", "4"`, status: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		testHeaders(t, map[string]string{fiber.HeaderIfMatch: tt.header}, func(c *fiber.Ctx) {
+			version, err := parseIfMatch(c)
+			status := 0
+			var apiErr *APIError
+			if errors.As(err, &apiErr) {
+				status = apiErr.Status
+			} else if err != nil {
+				t.Errorf("parseIfMatch(%q) error = %v, want APIError", tt.header, err)
+			}
+			if version != tt.version || status != tt.status {
+				t.Errorf("parseIfMatch(%q) = %d, status %d, want %d, status %d", tt.header, version, status, tt.version, tt.status)
+			}
+		})
+	}
+}
+
+func TestNoneMatch(t *testing.T) {
+	sub := &subscriptions.Subscription{Version: 5}
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{header: "", want: false},
+		{header: "*", want: true},
+		{header: `"5"`, want: true},
+		{header: `W/"5"`, want: true},
+		{header: `"4", "5"`, want: true},
+		{header: `"4"`, want: false},
+	}
+	for _, tt := range tests {
+		testHeaders(t, map[string]string{fiber.HeaderIfNoneMatch: tt.header}, func(c *fiber.Ctx) {
+			if got := noneMatch(c, sub); got != tt.want {
+				t.Errorf("noneMatch(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+
+	if got := etag(sub); got != `"5"` {
+		t.Errorf("etag = %s, want \"5\"", got)
+	}
+}
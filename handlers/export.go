@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/subscriptions_api/internal/logger"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+// форматы выгрузки
+const (
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+)
+
+// exportFlushRows - через сколько строк выгрузка отправляется клиенту
+const exportFlushRows = 500
+
+// exportContentTypes - Content-Type ответа для формата выгрузки
+var exportContentTypes = map[string]string{
+	exportFormatCSV:    "text/csv; charset=utf-8",
+	exportFormatNDJSON: "application/x-ndjson",
+}
+
+// subscriptionExportHeader - колонки CSV выгрузки подписок, совместимые с импортом
+var subscriptionExportHeader = []string{"subscription_id", "service_id", "service_name", "price", "currency", "billing_period", "billing_months", "user_id", "start_date", "end_date", "version"}
+
+// exportEncoder записывает строки выгрузки в CSV или NDJSON
+type exportEncoder struct {
+	csv  *csv.Writer
+	json *json.Encoder
+}
+
+// newExportEncoder создает кодировщик и для CSV сразу пишет заголовок
+func newExportEncoder(format string, w io.Writer, header []string) (*exportEncoder, error) {
+	if format == exportFormatNDJSON {
+		return &exportEncoder{json: json.NewEncoder(w)}, nil
+	}
+	enc := &exportEncoder{csv: csv.NewWriter(w)}
+	return enc, enc.csv.Write(header)
+}
+
+// encode пишет строку: record - колонки для CSV, value - объект для NDJSON
+func (e *exportEncoder) encode(record []string, value any) error {
+	if e.csv != nil {
+		return e.csv.Write(record)
+	}
+	return e.json.Encode(value)
+}
+
+func (e *exportEncoder) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		return e.csv.Error()
+	}
+	return nil
+}
+
+// parseExportFormat достает формат выгрузки, по умолчанию csv
+func parseExportFormat(c *fiber.Ctx) (string, error) {
+	format := c.Query("format", exportFormatCSV)
+	if _, ok := exportContentTypes[format]; !ok {
+		return "", badRequest("Неверный формат выгрузки", "format", nil)
+	}
+	return format, nil
+}
+
+// setExportHeaders проставляет Content-Type и имя файла выгрузки
+func setExportHeaders(c *fiber.Ctx, format, name string) {
+	c.Set(fiber.HeaderContentType, exportContentTypes[format])
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+name+"."+format+`"`)
+}
+
+// ExportSubscriptions godoc
+// @Summary Выгрузить записи о подписках
+// @Description Потоково выгружает все записи о подписках, подходящие под фильтры списка, в формате CSV или NDJSON.
+// @Description Строки отправляются клиенту по мере чтения из БД; limit и cursor не учитываются.
+// @Description CSV выгрузка имеет те же колонки, что и файл импорта
+// @Tags Subscriptions
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "Формат выгрузки" Enums(csv, ndjson) default(csv)
+// @Param sort_by query string false "Поле сортировки" Enums(price, start_date, service_name)
+// @Param order query string false "Направление сортировки" Enums(asc, desc)
+// @Param user_id query string false "UUID пользователя" format(uuid)
+// @Param service_name query string false "Название сервиса или его синоним"
+// @Param price_min query string false "Минимальная стоимость в единицах валюты" example(399.99)
+// @Param price_max query string false "Максимальная стоимость в единицах валюты" example(999.99)
+// @Param active_at query string false "Месяц, в котором подписка активна" format(MM-YYYY)
+// @Param as_of query string false "Момент, на который восстановить состояние подписок (RFC 3339), не раньше начала журнала изменений" format(date-time)
+// @Success 200 {string} string "Файл выгрузки"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/subscriptions/export [get]
+func (h *Handler) ExportSubscriptions(c *fiber.Ctx) error {
+	format, err := parseExportFormat(c)
+	if err != nil {
+		return sendError(c, "wrong export format", err)
+	}
+	filter, err := parseListFilter(c)
+	if err != nil {
+		return sendError(c, "wrong export params", err)
+	}
+	// доступ к подпискам и as_of проверяются до начала ответа: после него статус уже не изменить
+	export, err := h.repo.ExportSubscriptions(c.UserContext(), filter)
+	if err != nil {
+		return sendError(c, "failed ExportSubscriptions request", err)
+	}
+
+	setExportHeaders(c, format, "subscriptions")
+
+	// тело ответа пишется после выхода из обработчика, поэтому контекст запроса сохраняем заранее.
+	// Статус уже отправлен, так что ошибки посреди выгрузки только логируем и обрываем ответ
+	requestCtx := c.UserContext()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// ошибка записи означает, что клиент отключился: запрос к БД отменяется, не дочитывая строки
+		ctx, cancel := context.WithCancel(requestCtx)
+		defer cancel()
+		abort := func(err error) error {
+			cancel()
+			return err
+		}
+
+		enc, err := newExportEncoder(format, w, subscriptionExportHeader)
+		if err != nil {
+			logger.L.Error("failed ExportSubscriptions request", "error", err)
+			return
+		}
+
+		rows := 0
+		err = export.Each(ctx, func(sub *subscriptions.Subscription) error {
+			if err := enc.encode(subscriptionRecord(sub), sub); err != nil {
+				return abort(err)
+			}
+			rows++
+			if rows%exportFlushRows != 0 {
+				return nil
+			}
+			// отдаем строки клиенту
+			if err := enc.flush(); err != nil {
+				return abort(err)
+			}
+			if err := w.Flush(); err != nil {
+				return abort(err)
+			}
+			return nil
+		})
+		if err == nil {
+			err = enc.flush()
+		}
+		if err != nil {
+			logger.L.Error("failed ExportSubscriptions request", "format", format, "rows", rows, "error", err)
+			return
+		}
+		logger.L.Info("success ExportSubscriptions request", "format", format, "rows", rows)
+	})
+	return nil
+}
+
+// subscriptionRecord переводит подписку в строку CSV выгрузки
+func subscriptionRecord(sub *subscriptions.Subscription) []string {
+	endDate := ""
+	if sub.EndDate != nil {
+		endDate = *sub.EndDate
+	}
+	billingMonths := ""
+	if sub.BillingMonths != 0 {
+		billingMonths = strconv.Itoa(sub.BillingMonths)
+	}
+	return []string{
+		strconv.Itoa(sub.ID), strconv.Itoa(sub.ServiceID), sub.ServiceName, sub.Price.String(), sub.Currency,
+		sub.BillingPeriod, billingMonths, sub.UserID.String(), sub.StartDate, endDate, strconv.Itoa(sub.Version),
+	}
+}
+
+// exportMonthTotal - строка выгрузки помесячной разбивки
+type exportMonthTotal struct {
+	Group string `json:"group,omitempty"`
+	subscriptions.MonthTotal
+}
+
+// ExportMonthlyBreakdown godoc
+// @Summary Выгрузить помесячную разбивку стоимости подписок
+// @Description Выгружает помесячную разбивку в формате CSV (колонки month,total,count, с group_by - group,month,total,count)
+// @Description или NDJSON (один месяц на строку)
+// @Tags Subscriptions
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "Формат выгрузки" Enums(csv, ndjson) default(csv)
+// @Param start_date query string true "Начало периода" format(MM-YYYY)
+// @Param end_date query string true "Конец периода" format(MM-YYYY)
+// @Param user_id query string false "UUID пользователя" format(uuid)
+// @Param service_name query string false "Название сервиса или его синоним"
+// @Param group_by query string false "Поле группировки" Enums(service_name, user_id)
+// @Param mode query string false "Режим подсчета" Enums(overlap-months, prorated) default(overlap-months)
+// @Param as_of query string false "Момент, на который восстановить состояние подписок (RFC 3339), не раньше начала журнала изменений" format(date-time)
+// @Param currency query string false "Валюта результата (ISO 4217); цены пересчитываются по курсу каждого месяца" default(RUB)
+// @Param amortize query bool false "Распределять списания равномерно по месяцам периода списания вместо учета фактических списаний" default(false)
+// @Success 200 {string} string "Файл выгрузки"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/total/breakdown/export [get]
+func (h *Handler) ExportMonthlyBreakdown(c *fiber.Ctx) error {
+	format, err := parseExportFormat(c)
+	if err != nil {
+		return sendError(c, "wrong export format", err)
+	}
+	filter, err := parseTotalFilter(c)
+	if err != nil {
+		return sendError(c, "wrong breakdown params", err)
+	}
+	groupBy := c.Query("group_by")
+	if err := subscriptions.ValidateBreakdown(filter, groupBy); err != nil {
+		return sendError(c, "failed Validation breakdown params", err)
+	}
+
+	// разбивка ограничена MaxBreakdownMonths месяцами на группу, поэтому считается целиком до начала ответа
+	groups, err := h.repo.GetMonthlyBreakdown(c.UserContext(), filter, groupBy)
+	if err != nil {
+		return sendError(c, "failed ExportMonthlyBreakdown request", err)
+	}
+
+	setExportHeaders(c, format, "breakdown")
+	if err := writeBreakdown(c.Response().BodyWriter(), format, groupBy, groups); err != nil {
+		return sendError(c, "failed ExportMonthlyBreakdown request", err)
+	}
+
+	logger.L.Info("success ExportMonthlyBreakdown request", "format", format, "group_by", groupBy, "groups", len(groups))
+	return nil
+}
+
+// writeBreakdown пишет помесячную разбивку; колонка group выводится только при группировке
+func writeBreakdown(w io.Writer, format, groupBy string, groups []subscriptions.SeriesGroup) error {
+	header := []string{"month", "total", "count"}
+	if groupBy != "" {
+		header = append([]string{"group"}, header...)
+	}
+	enc, err := newExportEncoder(format, w, header)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		for _, month := range group.Series {
+			record := []string{month.Month, month.Total.String(), strconv.Itoa(month.Count)}
+			if groupBy != "" {
+				record = append([]string{group.Group}, record...)
+			}
+			if err := enc.encode(record, exportMonthTotal{Group: group.Group, MonthTotal: month}); err != nil {
+				return err
+			}
+		}
+	}
+	return enc.flush()
+}
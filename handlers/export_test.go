@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofrs/uuid"
+	"github.com/subscriptions_api/internal/auth"
+	"github.com/subscriptions_api/internal/importer"
+	"github.com/subscriptions_api/internal/repository"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+func TestSubscriptionRecord(t *testing.T) {
+	userID := uuid.Must(uuid.FromString("60601fee-2bf1-4721-ae6f-7636e79a0cba"))
+	endDate := "12-2025"
+	tests := []struct {
+		name string
+		sub  subscriptions.Subscription
+		want string
+	}{
+		{
+			name: "open-ended",
+			sub: subscriptions.Subscription{ID: 1, ServiceID: 2, ServiceName: "Yandex Plus", Price: 39999, Currency: "RUB",
+				BillingPeriod: subscriptions.BillingMonthly, UserID: userID, StartDate: "07-2025", Version: 3},
+			want: "1,2,Yandex Plus,399.99,RUB,monthly,," + userID.String() + ",07-2025,,3",
+		},
+		{
+			name: "custom period with end date",
+			sub: subscriptions.Subscription{ID: 4, ServiceID: 5, ServiceName: "Okko, Premium", Price: 100000, Currency: "USD",
+				BillingPeriod: subscriptions.BillingCustom, BillingMonths: 2, UserID: userID, StartDate: "01-2025", EndDate: &endDate, Version: 1},
+			want: `4,5,"Okko, Premium",1000,USD,custom,2,` + userID.String() + ",01-2025,12-2025,1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc, err := newExportEncoder(exportFormatCSV, &buf, subscriptionExportHeader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := enc.encode(subscriptionRecord(&tt.sub), &tt.sub); err != nil {
+				t.Fatal(err)
+			}
+			if err := enc.flush(); err != nil {
+				t.Fatal(err)
+			}
+			want := strings.Join(subscriptionExportHeader, ",") + "\n" + tt.want + "\n"
+			if buf.String() != want {
+				t.Errorf("export = %q, want %q", buf.String(), want)
+			}
+
+			// выгрузка читается импортом без ошибок
+			report, err := importer.Run(context.Background(), nil, &buf, importer.FormatCSV, true)
+			if err != nil {
+				t.Fatalf("import: %v", err)
+			}
+			if report.Valid != 1 || report.Failed != 0 {
+				t.Errorf("import report = %+v", report)
+			}
+		})
+	}
+}
+
+func TestExportSubscriptionsError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{name: "forbidden", err: auth.ErrForbidden, status: fiber.StatusForbidden, code: CodeForbidden},
+		{name: "as_of before journal", err: repository.ErrAsOfBeforeJournal, status: fiber.StatusUnprocessableEntity, code: CodeValidationFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", New(&stubRepo{exportErr: tt.err}).ExportSubscriptions)
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/?format=ndjson", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			// ошибка отдается обычным ответом с ошибкой, а не обрывом выгрузки со статусом 200
+			var body ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.StatusCode != tt.status || body.Code != tt.code {
+				t.Errorf("response = %d %+v, want %d %s", resp.StatusCode, body, tt.status, tt.code)
+			}
+			if disposition := resp.Header.Get(fiber.HeaderContentDisposition); disposition != "" {
+				t.Errorf("Content-Disposition = %q, want empty", disposition)
+			}
+		})
+	}
+}
+
+func TestWriteBreakdown(t *testing.T) {
+	series := []subscriptions.MonthTotal{{Month: "01-2025", Total: 99900, Count: 1}, {Month: "02-2025", Total: 0}}
+	// без группировки разбивка состоит из одной группы с пустым названием
+	total := []subscriptions.SeriesGroup{{Series: series}}
+	groups := []subscriptions.SeriesGroup{
+		{Group: "Netflix", Series: series},
+		{Group: "Okko", Series: []subscriptions.MonthTotal{{Month: "01-2025", Total: 39950, Count: 2}}},
+	}
+	tests := []struct {
+		name    string
+		format  string
+		groupBy string
+		groups  []subscriptions.SeriesGroup
+		want    string
+	}{
+		{
+			name: "csv", format: exportFormatCSV, groups: total,
+			want: "month,total,count\n01-2025,999,1\n02-2025,0,0\n",
+		},
+		{
+			name: "csv grouped", format: exportFormatCSV, groupBy: "service_name", groups: groups,
+			want: "group,month,total,count\nNetflix,01-2025,999,1\nNetflix,02-2025,0,0\nOkko,01-2025,399.50,2\n",
+		},
+		{
+			name: "ndjson", format: exportFormatNDJSON, groups: total,
+			want: `{"month":"01-2025","total":999,"count":1}` + "\n" + `{"month":"02-2025","total":0,"count":0}` + "\n",
+		},
+		{
+			name: "ndjson grouped", format: exportFormatNDJSON, groupBy: "service_name", groups: groups[1:],
+			want: `{"group":"Okko","month":"01-2025","total":399.50,"count":2}` + "\n",
+		},
+		{
+			name: "empty", format: exportFormatCSV, want: "month,total,count\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeBreakdown(&buf, tt.format, tt.groupBy, tt.groups); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("writeBreakdown = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/subscriptions_api/internal/logger"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+// GetForecast godoc
+// @Summary Получить прогноз списаний
+// @Description Прогнозирует списания подписок с завтрашнего дня до конца months-го месяца после текущего по их периодам списания
+// @Description (как в режиме overlap-months у /api/total) и запланированным изменениям цены. Учитываются подписки без даты окончания
+// @Description или заканчивающиеся в период прогноза. Остаток текущего месяца входит в прогноз: учитываются списания после сегодняшнего дня;
+// @Description границы окна возвращаются в from и to. Возвращает сумму за период, суммы по месяцам и по месяцам для каждого сервиса
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param months query int false "Число месяцев прогноза после текущего" minimum(1) maximum(36) default(6)
+// @Param user_id query string false "UUID пользователя" format(uuid)
+// @Param currency query string false "Валюта результата (ISO 4217); цены пересчитываются по курсу каждого месяца" default(RUB)
+// @Success 200 {object} subscriptions.Forecast
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/forecast [get]
+func (h *Handler) GetForecast(c *fiber.Ctx) error {
+
+	filter, err := parseForecastFilter(c)
+	if err != nil {
+		return sendError(c, "wrong forecast params", err)
+	}
+
+	// запрос к БД
+	forecast, err := h.repo.GetForecast(c.UserContext(), filter, time.Now())
+	if err != nil {
+		return sendError(c, "failed GetForecast request", err)
+	}
+
+	// успешный ответ
+	logger.L.Info("success GetForecast request", "months", filter.Months, "services", len(forecast.Services))
+	return c.Status(fiber.StatusOK).JSON(forecast)
+}
+
+// parseForecastFilter достает и валидирует параметры прогноза списаний
+func parseForecastFilter(c *fiber.Ctx) (*subscriptions.ForecastFilter, error) {
+	filter := &subscriptions.ForecastFilter{
+		Currency: strings.ToUpper(c.Query("currency")),
+	}
+
+	if months := c.Query("months"); months != "" {
+		value, err := strconv.Atoi(months)
+		if err != nil {
+			return nil, badRequest("Неверный формат months", "months", err)
+		}
+		filter.Months = value
+	}
+
+	userUUID, err := parseUserID(c)
+	if err != nil {
+		return nil, err
+	}
+	filter.UserID = userUUID
+
+	if err := subscriptions.ValidateForecastFilter(filter); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
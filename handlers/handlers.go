@@ -1,8 +1,10 @@
 package handlers
 
 import (
-	"context"
-	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofrs/uuid"
@@ -11,6 +13,15 @@ import (
 	"github.com/subscriptions_api/subscriptions"
 )
 
+// Handler содержит обработчики HTTP-запросов и их зависимости
+type Handler struct {
+	repo repository.SubscriptionRepository
+}
+
+func New(repo repository.SubscriptionRepository) *Handler {
+	return &Handler{repo: repo}
+}
+
 // CreateSubscription godoc
 // @Summary Создать запись о подписке
 // @Description Создает новую запись о подписке
@@ -18,76 +29,98 @@ import (
 // @Accept json
 // @Produce json
 // @Param subscription body subscriptions.Subscription true "Данные подписки"
-// @Success 201 {object} map[string]interface{} "Запись о подписке успешно создана"
-// @Failure 400 {object} map[string]interface{} "'error': 'message'"
-// @Failure 500 {object}  map[string]interface{} "'error': 'message'"
+// @Success 201 {object} subscriptions.Subscription "Созданная запись о подписке"
+// @Header 201 {string} Location "Адрес созданной записи"
+// @Header 201 {string} ETag "Версия записи"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /api/subscriptions [post]
-func CreateSubscription(c *fiber.Ctx) error {
+func (h *Handler) CreateSubscription(c *fiber.Ctx) error {
 	var sub subscriptions.Subscription
 
 	//парсим JSON в структуру subscription
 	if err := c.BodyParser(&sub); err != nil {
-		logger.L.Error("failed parse subscrption", "error", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Неверный формат данных"})
+		return sendError(c, "failed parse subscrption", badRequest("Неверный формат данных", "", err))
 	}
 
 	// провалидируем полученные данные
-	err := subscriptions.Validate(&sub)
-	if err != nil {
-		if errors.Is(err, subscriptions.ErrWrongPrice) {
-			logger.L.Error("Invalid price", "price", sub.Price)
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Стоимость не может быть отрицательной"})
-		}
-
-		if errors.Is(err, subscriptions.ErrWrongFormatDate) {
-			logger.L.Error("Invalid date format", "start_date", sub.StartDate, "end_date", sub.EndDate)
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Неправильно указан формат даты"})
-		}
-
-		if errors.Is(err, subscriptions.ErrWrongDatesInterval) {
-			logger.L.Error("end_date befor start_date", "start_date", sub.StartDate, "end_date", sub.EndDate)
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Дата окончания не может быть меньше даты начала"})
-		}
-		logger.L.Error("failed Validation subscription", "error", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	if err := subscriptions.Validate(&sub); err != nil {
+		return sendError(c, "failed Validation subscription", err)
 	}
 
 	// запрос к БД на добавление записи
-	err = repository.CreateSubscription(context.Background(), &sub)
-	if err != nil {
-		logger.L.Error("failed CreateSubscription request", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	if err := h.repo.CreateSubscription(c.UserContext(), &sub); err != nil {
+		return sendError(c, "failed CreateSubscription request", err)
 	}
 	// успешное добавление записи
-	logger.L.Info("success CreateSubscription request")
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"message": "Запись о подписке успешно создана"})
+	logger.L.Info("success CreateSubscription request", "subscription_id", sub.ID)
+	c.Location(fmt.Sprintf("/api/subscriptions/%d", sub.ID))
+	setETag(c, &sub)
+	return c.Status(fiber.StatusCreated).JSON(sub)
 }
 
 // GetSubscription godoc
 // @Summary Получить данные о подписке
-// @Description Возвращает данные о подписке по ее id
+// @Description Возвращает данные о подписке по ее id. Версия записи возвращается в заголовке ETag;
+// @Description при совпадении If-None-Match с текущей версией возвращается 304 без тела
+// @Description С as_of возвращается состояние записи на этот момент, восстановленное по журналу изменений, без ETag
 // @Tags Subscriptions
 // @Accept json
 // @Produce json
 // @Param id path int true "ID подписки"
+// @Param If-None-Match header string false "ETag закешированной версии"
+// @Param as_of query string false "Момент, на который восстановить состояние подписок (RFC 3339), не раньше начала журнала изменений" format(date-time)
 // @Success 200 {object} subscriptions.Subscription
-// @Failure 400 {object} map[string]interface{} "'error': 'message'"
-// @Failure 500 {object}  map[string]interface{} "'error': 'message'"
+// @Header 200 {string} ETag "Версия записи"
+// @Success 304 "Запись не изменилась"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /api/subscriptions/{id} [get]
-func GetSubscription(c *fiber.Ctx) error {
+func (h *Handler) GetSubscription(c *fiber.Ctx) error {
 
 	// провалидируем id
-	id, err := c.ParamsInt("id")
+	id, err := parseID(c)
+	if err != nil {
+		return sendError(c, "wrong id format", err)
+	}
+
+	asOf, err := parseAsOf(c)
 	if err != nil {
-		logger.L.Error("wrong id format", "id", id)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Неверный формат id"})
+		return sendError(c, "wrong as_of format", err)
+	}
+
+	// состояние на прошлый момент отдаем без ETag: по нему нельзя делать условные изменения
+	if asOf != nil {
+		sub, err := h.repo.GetSubscriptionAsOf(c.UserContext(), id, *asOf)
+		if err != nil {
+			return sendError(c, "failed GetSubscription request", err)
+		}
+		logger.L.Info("success GetSubscription info request", "as_of", asOf)
+		return c.Status(fiber.StatusOK).JSON(sub)
 	}
 
 	//запрос к БД
-	sub, err := repository.GetSubscriptionById(context.Background(), id)
+	sub, err := h.repo.GetSubscriptionById(c.UserContext(), id)
 	if err != nil {
-		logger.L.Error("failed GetSubscription request", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return sendError(c, "failed GetSubscription request", err)
+	}
+
+	setETag(c, sub)
+	if noneMatch(c, sub) {
+		logger.L.Info("success GetSubscription info request, not modified")
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 
 	logger.L.Info("success GetSubscription info request")
@@ -96,91 +129,155 @@ func GetSubscription(c *fiber.Ctx) error {
 
 // UpdateSubscription godoc
 // @Summary Обновить данные о подписке
-// @Description Обновляет данные о подписке по ее id
+// @Description Обновляет данные о подписке по ее id. При переданном If-Match запись обновляется, только если ее версия не изменилась
 // @Tags Subscriptions
 // @Accept json
 // @Produce json
 // @Param id path int true "ID подписки"
+// @Param If-Match header string false "ETag версии, на основе которой сделаны изменения"
 // @Param subscription body subscriptions.Subscription true "Данные подписки"
 // @Success 200 {object} map[string]interface{} "Запись о подписке успешно обновлена"
-// @Failure 400 {object} map[string]interface{} "'error': 'message'"
-// @Failure 500 {object}  map[string]interface{} "'error': 'message'"
+// @Header 200 {string} ETag "Новая версия записи"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /api/subscriptions/{id} [put]
-func UpdateSubscription(c *fiber.Ctx) error {
+func (h *Handler) UpdateSubscription(c *fiber.Ctx) error {
 
 	// провалидируем id
-	id, err := c.ParamsInt("id")
+	id, err := parseID(c)
 	if err != nil {
-		logger.L.Error("wrong id format", "id", id)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Неверный формат id"})
+		return sendError(c, "wrong id format", err)
 	}
 
 	var updatedSub subscriptions.Subscription
 	// парсим JSON в структуру subscription
 	if err := c.BodyParser(&updatedSub); err != nil {
-		logger.L.Error("failed parse updatedSubscrption", "error", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Неверный формат данных"})
+		return sendError(c, "failed parse updatedSubscrption", badRequest("Неверный формат данных", "", err))
 	}
 
-	// провалидируем полученные данные
-	err = subscriptions.Validate(&updatedSub)
+	expectedVersion, err := parseIfMatch(c)
 	if err != nil {
-		if errors.Is(err, subscriptions.ErrWrongPrice) {
-			logger.L.Error("Invalid price", "price", updatedSub.Price)
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Стоимость не может быть отрицательной"})
-		}
-
-		if errors.Is(err, subscriptions.ErrWrongFormatDate) {
-			logger.L.Error("Invalid date format", "start_date", updatedSub.StartDate, "end_date", updatedSub.EndDate)
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Неправильно указан формат даты"})
-		}
-
-		if errors.Is(err, subscriptions.ErrWrongDatesInterval) {
-			logger.L.Error("end_date befor start_date", "start_date", updatedSub.StartDate, "end_date", updatedSub.EndDate)
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Дата окончания не может быть меньше даты начала"})
-		}
+		return sendError(c, "wrong If-Match header", err)
+	}
 
-		logger.L.Error("failed Validation updatedSubscription", "error", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	// провалидируем полученные данные
+	if err := subscriptions.Validate(&updatedSub); err != nil {
+		return sendError(c, "failed Validation updatedSubscription", err)
 	}
 
 	// запрос к БД
-	err = repository.UpdateSubscriptionById(context.Background(), id, &updatedSub)
-	if err != nil {
-		logger.L.Error("failed UpdateSubscription request", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	if err := h.repo.UpdateSubscriptionById(c.UserContext(), id, expectedVersion, &updatedSub); err != nil {
+		return sendError(c, "failed UpdateSubscription request", err)
 	}
+	setETag(c, &updatedSub)
 
 	// успешное обновление записи
 	logger.L.Info("success UpdateSubscription request")
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Запись о подписке успешно обновлена"})
 }
 
+// PatchSubscription godoc
+// @Summary Частично обновить данные о подписке
+// @Description Применяет к подписке JSON Merge Patch (RFC 7396): переданные поля заменяются, поля со значением null удаляются, остальные не меняются.
+// @Description Например, {"end_date": "12-2025"} отменяет подписку, а {"end_date": null} делает ее снова бессрочной
+// @Tags Subscriptions
+// @Accept json
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param If-Match header string false "ETag версии, на основе которой сделаны изменения"
+// @Param patch body subscriptions.Subscription true "Изменяемые поля подписки"
+// @Success 200 {object} subscriptions.Subscription "Обновленная запись о подписке"
+// @Header 200 {string} ETag "Новая версия записи"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/subscriptions/{id} [patch]
+func (h *Handler) PatchSubscription(c *fiber.Ctx) error {
+
+	// провалидируем id
+	id, err := parseID(c)
+	if err != nil {
+		return sendError(c, "wrong id format", err)
+	}
+
+	expectedVersion, err := parseIfMatch(c)
+	if err != nil {
+		return sendError(c, "wrong If-Match header", err)
+	}
+
+	body := c.Body()
+	// применяем патч к текущему состоянию и валидируем уже объединенный результат
+	patchedSub, err := h.repo.PatchSubscriptionById(c.UserContext(), id, expectedVersion, func(sub *subscriptions.Subscription) (*subscriptions.Subscription, error) {
+		patched, err := subscriptions.MergePatch(sub, body)
+		if err != nil {
+			return nil, badRequest("Неверный формат данных", "", err)
+		}
+		if err := subscriptions.Validate(patched); err != nil {
+			return nil, err
+		}
+		return patched, nil
+	})
+	if err != nil {
+		return sendError(c, "failed PatchSubscription request", err)
+	}
+
+	// успешное обновление записи
+	logger.L.Info("success PatchSubscription request", "subscription_id", id)
+	setETag(c, patchedSub)
+	return c.Status(fiber.StatusOK).JSON(patchedSub)
+}
+
 // DeleteSubscription godoc
 // @Summary Удалить запись о подписке
-// @Description Удаляет запись о подписке по id
+// @Description Помечает запись о подписке удаленной: она исключается из выборок и подсчетов и может быть восстановлена
+// @Description через POST /api/subscriptions/{id}/restore до истечения срока хранения. При переданном If-Match запись удаляется, только если ее версия не изменилась
 // @Tags Subscriptions
 // @Accept json
 // @Produce json
 // @Param id path int true "ID подписки"
+// @Param If-Match header string false "ETag версии, которую нужно удалить"
 // @Success 200 {object} map[string]interface{} "Задача успешно удалена"
-// @Failure 400 {object} map[string]interface{} "'error': 'message'"
-// @Failure 500 {object}  map[string]interface{} "'error': 'message'"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /api/subscriptions/{id} [delete]
-func DeleteSubscription(c *fiber.Ctx) error {
+func (h *Handler) DeleteSubscription(c *fiber.Ctx) error {
 
 	// провалидируем id
-	id, err := c.ParamsInt("id")
+	id, err := parseID(c)
 	if err != nil {
-		logger.L.Error("wrong id format", "id", id)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Неверный формат id"})
+		return sendError(c, "wrong id format", err)
 	}
 
-	// запрос к БД
-	err = repository.DeleteSubscriptionById(context.Background(), id)
+	expectedVersion, err := parseIfMatch(c)
 	if err != nil {
-		logger.L.Error("failed DeleteSubscription request", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return sendError(c, "wrong If-Match header", err)
+	}
+
+	// запрос к БД
+	if err := h.repo.DeleteSubscriptionById(c.UserContext(), id, expectedVersion); err != nil {
+		return sendError(c, "failed DeleteSubscription request", err)
 	}
 
 	// успешный ответ
@@ -190,100 +287,352 @@ func DeleteSubscription(c *fiber.Ctx) error {
 	})
 }
 
+// RestoreSubscription godoc
+// @Summary Восстановить удаленную запись о подписке
+// @Description Снимает пометку удаления с записи о подписке. При переданном If-Match запись восстанавливается, только если ее версия не изменилась
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param If-Match header string false "ETag версии, которую нужно восстановить"
+// @Success 200 {object} subscriptions.Subscription
+// @Header 200 {string} ETag "Новая версия записи"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Запись не удалена"
+// @Failure 412 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/subscriptions/{id}/restore [post]
+func (h *Handler) RestoreSubscription(c *fiber.Ctx) error {
+
+	// провалидируем id
+	id, err := parseID(c)
+	if err != nil {
+		return sendError(c, "wrong id format", err)
+	}
+
+	expectedVersion, err := parseIfMatch(c)
+	if err != nil {
+		return sendError(c, "wrong If-Match header", err)
+	}
+
+	// запрос к БД
+	sub, err := h.repo.RestoreSubscriptionById(c.UserContext(), id, expectedVersion)
+	if err != nil {
+		return sendError(c, "failed RestoreSubscription request", err)
+	}
+	setETag(c, sub)
+
+	// успешный ответ
+	logger.L.Info("success RestoreSubscription request")
+	return c.Status(fiber.StatusOK).JSON(sub)
+}
+
+// GetSubscriptionHistory godoc
+// @Summary Получить историю изменений подписки
+// @Description Возвращает журнал изменений подписки: операцию, автора, время и состояние строки до и после изменения.
+// @Description История остается доступной после удаления подписки
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Success 200 {array} subscriptions.Event
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/subscriptions/{id}/history [get]
+func (h *Handler) GetSubscriptionHistory(c *fiber.Ctx) error {
+
+	// провалидируем id
+	id, err := parseID(c)
+	if err != nil {
+		return sendError(c, "wrong id format", err)
+	}
+
+	// запрос к БД
+	events, err := h.repo.GetSubscriptionHistory(c.UserContext(), id)
+	if err != nil {
+		return sendError(c, "failed GetSubscriptionHistory request", err)
+	}
+
+	// успешный ответ
+	logger.L.Info("success GetSubscriptionHistory request", "events", len(events))
+	return c.Status(fiber.StatusOK).JSON(events)
+}
+
 // GetAllSubscriptions godoc
-// @Summary Получить все записи о подписках
-// @Description Возвращает все имеющиеся записи о подписках
+// @Summary Получить записи о подписках
+// @Description Возвращает страницу записей о подписках с фильтрацией и сортировкой. Для получения следующей страницы передайте next_cursor в параметре cursor
 // @Tags Subscriptions
 // @Accept json
 // @Produce json
-// @Success 200 {array} subscriptions.Subscription
-// @Failure 400 {object} map[string]interface{} "'error': 'message'"
-// @Failure 500 {object}  map[string]interface{} "'error': 'message'"
+// @Param limit query int false "Размер страницы (по умолчанию 50, максимум 500)"
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param sort_by query string false "Поле сортировки" Enums(price, start_date, service_name)
+// @Param order query string false "Направление сортировки" Enums(asc, desc)
+// @Param user_id query string false "UUID пользователя" format(uuid)
+// @Param service_name query string false "Название сервиса или его синоним"
+// @Param price_min query string false "Минимальная стоимость в единицах валюты" example(399.99)
+// @Param price_max query string false "Максимальная стоимость в единицах валюты" example(999.99)
+// @Param active_at query string false "Месяц, в котором подписка активна" format(MM-YYYY)
+// @Param as_of query string false "Момент, на который восстановить состояние подписок (RFC 3339), не раньше начала журнала изменений" format(date-time)
+// @Success 200 {object} subscriptions.Page
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /api/subscriptions [get]
-func GetAllSubscriptions(c *fiber.Ctx) error {
+func (h *Handler) GetAllSubscriptions(c *fiber.Ctx) error {
+
+	filter, err := parseListFilter(c)
+	if err != nil {
+		return sendError(c, "wrong list params", err)
+	}
 
 	// запрос к БД
-	subs, err := repository.GetAllSubscriptions(context.Background())
+	page, err := h.repo.GetAllSubscriptions(c.UserContext(), filter)
 	if err != nil {
-		logger.L.Error("failed GetAllSubscriptions request", "error", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return sendError(c, "failed GetAllSubscriptions request", err)
 	}
 
 	//успешный ответ
 	logger.L.Info("success GetAllSubscriptions request")
-	return c.Status(fiber.StatusOK).JSON(subs)
+	return c.Status(fiber.StatusOK).JSON(page)
 }
 
 // GetTotalPriceInPeriod godoc
 // @Summary Получить суммарную стоимость подписок
-// @Description Возвращает суммарную стоимость подписок за выбранный период с фильтрацией по user_id и service_name
+// @Description Возвращает суммарную стоимость подписок за выбранный период с фильтрацией по user_id и service_name.
+// @Description В режиме overlap-months (по умолчанию) учитываются списания подписки в ее месяцах, попавших в период: ежемесячная - каждый месяц,
+// @Description годовая - в месяц годовщины, еженедельная - каждые 7 дней; бессрочные подписки обрезаются концом периода. С amortize=true списание распределяется равномерно по месяцам.
+// @Description Для подписок с датами YYYY-MM-DD списания приходятся на день начала подписки и не учитываются после дня ее окончания.
+// @Description В режиме prorated списание распределяется по месяцам как с amortize=true, а неполные месяцы подписки учитываются пропорционально числу активных дней.
+// @Description В режиме contained учитываются только подписки, целиком лежащие в периоде, по одному списанию за подписку по цене и курсу месяца ее начала, без изменений цены.
+// @Description Цены в других валютах пересчитываются в currency по курсу, действующему в каждом месяце; с as_of - по курсам, загруженным до этого момента
 // @Tags Subscriptions
 // @Accept json
 // @Produce json
 // @Param start_date query string true "Начало периода" format(MM-YYYY)
 // @Param end_date query string true "Конец периода" format(MM-YYYY)
-// @Param user_id query uuid.UUID false "UUID пользователя"
-// @Param service_name query string false "Название сервиса"
-// @Success 200 {number} int
-// @Failure 400 {object} map[string]interface{} "'error': 'message'"
-// @Failure 500 {object}  map[string]interface{} "'error': 'message'"
+// @Param user_id query string false "UUID пользователя" format(uuid)
+// @Param service_name query string false "Название сервиса или его синоним"
+// @Param mode query string false "Режим подсчета" Enums(overlap-months, prorated, contained) default(overlap-months)
+// @Param as_of query string false "Момент, на который восстановить состояние подписок (RFC 3339), не раньше начала журнала изменений" format(date-time)
+// @Param currency query string false "Валюта результата (ISO 4217); цены пересчитываются по курсу каждого месяца" default(RUB)
+// @Param amortize query bool false "Распределять списания равномерно по месяцам периода списания вместо учета фактических списаний" default(false)
+// @Success 200 {number} number
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /api/total [get]
-func GetTotalPriceInPeriod(c *fiber.Ctx) error {
+func (h *Handler) GetTotalPriceInPeriod(c *fiber.Ctx) error {
 
 	// Парсим параметры
 	// даты - обязтельные параметры
-	//userID и serviceName опциональные
-	startDate := c.Query("start_date")
-	endDate := c.Query("end_date")
-	userID := c.Query("user_id")
-	serviceName := c.Query("service_name")
+	// userID, serviceName и mode опциональные
+	filter, err := parseTotalFilter(c)
+	if err != nil {
+		return sendError(c, "wrong total params", err)
+	}
 
-	// валидация дат
-	if startDate == "" || endDate == "" {
-		logger.L.Error("dates are required", "start_date", startDate, "end_date", endDate)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "даты должны быть указаны"})
-	}
-	// создадим подписку-валидатор и запишем туда query параметры
-	//с ее помощью: провалидируем даты, и используем ее поля для фильтрации
-	var validatorSub subscriptions.Subscription
-	validatorSub.StartDate = startDate
-	validatorSub.EndDate = &endDate
-	validatorSub.ServiceName = serviceName
-
-	err := subscriptions.Validate(&validatorSub)
+	// запрос к БД
+	count, err := h.repo.GetTotalPriceInPeriod(c.UserContext(), filter)
 	if err != nil {
-		if errors.Is(err, subscriptions.ErrWrongFormatDate) {
-			logger.L.Error("Invalid date format", "start_date", startDate, "end_date", endDate)
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Неправильно указан формат даты"})
-		}
+		return sendError(c, "failed GetTotalPriceInPeriod request", err)
+	}
 
-		if errors.Is(err, subscriptions.ErrWrongDatesInterval) {
-			logger.L.Error("end_date befor start_date", "start_date", startDate, "end_date", endDate)
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Дата окончания не может быть меньше даты начала"})
-		}
-		logger.L.Error("failed Validation validatorSubscription", "error", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	// успешный ответ
+	logger.L.Info("success GetTotalPriceInPeriod request", "mode", filter.Mode)
+	return c.Status(fiber.StatusOK).JSON(count)
+}
+
+// GetMonthlyBreakdown godoc
+// @Summary Получить помесячную разбивку стоимости подписок
+// @Description Возвращает для каждого месяца периода суммарную стоимость и число активных подписок, включая месяцы без подписок.
+// @Description Без group_by возвращается массив MonthTotal, с group_by - массив SeriesGroup, по одной серии на группу
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param start_date query string true "Начало периода" format(MM-YYYY)
+// @Param end_date query string true "Конец периода" format(MM-YYYY)
+// @Param user_id query string false "UUID пользователя" format(uuid)
+// @Param service_name query string false "Название сервиса или его синоним"
+// @Param group_by query string false "Поле группировки" Enums(service_name, user_id)
+// @Param mode query string false "Режим подсчета" Enums(overlap-months, prorated) default(overlap-months)
+// @Param as_of query string false "Момент, на который восстановить состояние подписок (RFC 3339), не раньше начала журнала изменений" format(date-time)
+// @Param currency query string false "Валюта результата (ISO 4217); цены пересчитываются по курсу каждого месяца" default(RUB)
+// @Param amortize query bool false "Распределять списания равномерно по месяцам периода списания вместо учета фактических списаний" default(false)
+// @Success 200 {array} subscriptions.MonthTotal "Без group_by; с group_by - массив subscriptions.SeriesGroup"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/total/breakdown [get]
+func (h *Handler) GetMonthlyBreakdown(c *fiber.Ctx) error {
+
+	filter, err := parseTotalFilter(c)
+	if err != nil {
+		return sendError(c, "wrong breakdown params", err)
 	}
 
-	// парсим user_id
-	var userUUID uuid.UUID
-	if userID != "" {
-		userUUID, err = uuid.FromString(userID)
-		if err != nil {
-			logger.L.Error("wrong format of user_id", "user_id", userID)
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Некорректый userID"})
-		}
+	groupBy := c.Query("group_by")
+	if err := subscriptions.ValidateBreakdown(filter, groupBy); err != nil {
+		return sendError(c, "failed Validation breakdown params", err)
 	}
-	validatorSub.UserID = uuid.UUID(userUUID)
 
 	// запрос к БД
-	count, err := repository.GetTotalPriceInPeriod(context.Background(), &validatorSub)
+	groups, err := h.repo.GetMonthlyBreakdown(c.UserContext(), filter, groupBy)
 	if err != nil {
-		logger.L.Error("failed GetTotalPriceInPeriod request", "error", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return sendError(c, "failed GetMonthlyBreakdown request", err)
 	}
 
 	// успешный ответ
-	logger.L.Info("success GetTotalPriceInPeriod request")
-	return c.Status(fiber.StatusOK).JSON(count)
+	logger.L.Info("success GetMonthlyBreakdown request", "group_by", groupBy, "groups", len(groups))
+	if groupBy == "" {
+		return c.Status(fiber.StatusOK).JSON(groups[0].Series)
+	}
+	return c.Status(fiber.StatusOK).JSON(groups)
+}
+
+// parseListFilter достает и валидирует параметры выборки списка подписок
+func parseListFilter(c *fiber.Ctx) (*subscriptions.ListFilter, error) {
+	filter := &subscriptions.ListFilter{
+		Cursor:      c.Query("cursor"),
+		SortBy:      c.Query("sort_by"),
+		Order:       c.Query("order"),
+		ServiceName: c.Query("service_name"),
+		ActiveAt:    c.Query("active_at"),
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil {
+			return nil, badRequest("Неверный формат limit", "limit", err)
+		}
+		filter.Limit = value
+	}
+
+	userUUID, err := parseUserID(c)
+	if err != nil {
+		return nil, err
+	}
+	filter.UserID = userUUID
+
+	if filter.PriceMin, err = queryAmountPtr(c, "price_min"); err != nil {
+		return nil, err
+	}
+	if filter.PriceMax, err = queryAmountPtr(c, "price_max"); err != nil {
+		return nil, err
+	}
+	if filter.AsOf, err = parseAsOf(c); err != nil {
+		return nil, err
+	}
+
+	// провалидируем параметры выборки
+	if err := subscriptions.ValidateListFilter(filter); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// parseTotalFilter достает и валидирует параметры подсчета стоимости за период
+func parseTotalFilter(c *fiber.Ctx) (*subscriptions.TotalFilter, error) {
+	filter := &subscriptions.TotalFilter{
+		StartDate:   c.Query("start_date"),
+		EndDate:     c.Query("end_date"),
+		ServiceName: c.Query("service_name"),
+		Mode:        c.Query("mode"),
+		Currency:    strings.ToUpper(c.Query("currency")),
+		Amortize:    c.QueryBool("amortize", false),
+	}
+
+	// валидация дат
+	if filter.StartDate == "" {
+		return nil, badRequest("даты должны быть указаны", "start_date", nil)
+	}
+	if filter.EndDate == "" {
+		return nil, badRequest("даты должны быть указаны", "end_date", nil)
+	}
+
+	// парсим user_id
+	userUUID, err := parseUserID(c)
+	if err != nil {
+		return nil, err
+	}
+	filter.UserID = userUUID
+
+	if filter.AsOf, err = parseAsOf(c); err != nil {
+		return nil, err
+	}
+
+	if err := subscriptions.ValidateTotalFilter(filter); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// parseID достает id подписки из пути запроса
+func parseID(c *fiber.Ctx) (int, error) {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return 0, badRequest("Неверный формат id", "id", err)
+	}
+	return id, nil
+}
+
+// parseUserID достает опциональный query параметр user_id
+func parseUserID(c *fiber.Ctx) (uuid.UUID, error) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		return uuid.Nil, nil
+	}
+	userUUID, err := uuid.FromString(userID)
+	if err != nil {
+		return uuid.Nil, badRequest("Некорректый userID", "user_id", err)
+	}
+	return userUUID, nil
+}
+
+// parseAsOf достает опциональный момент as_of в формате RFC 3339
+func parseAsOf(c *fiber.Ctx) (*time.Time, error) {
+	raw := c.Query("as_of")
+	if raw == "" {
+		return nil, nil
+	}
+	asOf, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, badRequest("Неверный формат as_of", "as_of", err)
+	}
+	return &asOf, nil
+}
+
+// queryAmountPtr достает опциональную сумму в единицах валюты ("399.99") из query параметра
+func queryAmountPtr(c *fiber.Ctx, name string) (*subscriptions.Amount, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := subscriptions.ParseAmount(raw)
+	if err != nil {
+		return nil, badRequest("Неверный формат "+name, name, err)
+	}
+	return &value, nil
 }
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/subscriptions_api/internal/logger"
+	"github.com/subscriptions_api/internal/repository"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+func TestMain(m *testing.M) {
+	logger.L = slog.New(slog.NewTextHandler(io.Discard, nil))
+	os.Exit(m.Run())
+}
+
+// stubRepo - хранилище для тестов обработчиков; методы, не заданные в тесте, паникуют
+type stubRepo struct {
+	repository.SubscriptionRepository
+	applyBatch func(items []*subscriptions.BatchItem, atomic bool) []error
+	exportErr  error
+	restore    func(id, expectedVersion int) (*subscriptions.Subscription, error)
+	created    []*subscriptions.Subscription
+	createCtx  context.Context
+}
+
+// CreateSubscription запоминает подписку и присваивает ей следующий id
+func (r *stubRepo) CreateSubscription(ctx context.Context, sub *subscriptions.Subscription) error {
+	r.createCtx = ctx
+	r.created = append(r.created, sub)
+	sub.ID, sub.Version = len(r.created), 1
+	return nil
+}
+
+func (r *stubRepo) ApplyBatch(_ context.Context, items []*subscriptions.BatchItem, atomic bool) ([]error, error) {
+	return r.applyBatch(items, atomic), nil
+}
+
+func (r *stubRepo) RestoreSubscriptionById(_ context.Context, id int, expectedVersion int) (*subscriptions.Subscription, error) {
+	return r.restore(id, expectedVersion)
+}
+
+func (r *stubRepo) ExportSubscriptions(context.Context, *subscriptions.ListFilter) (*repository.SubscriptionExport, error) {
+	return nil, r.exportErr
+}
+
+func TestCreateSubscription(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		location string
+	}{
+		{
+			name:   "created",
+			body:   `{"service_name":"Yandex Plus","price":399,"user_id":"60601fee-2bf1-4721-ae6f-7636e79a0cba","start_date":"07-2025"}`,
+			status: fiber.StatusCreated, location: "/api/subscriptions/1",
+		},
+		{name: "invalid", body: `{"service_name":"Yandex Plus","price":-1,"start_date":"07-2025"}`, status: fiber.StatusUnprocessableEntity},
+		{name: "wrong date", body: `{"service_name":"Yandex Plus","price":399,"start_date":"2025-07"}`, status: fiber.StatusUnprocessableEntity},
+		{name: "wrong json", body: `{"price":`, status: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &stubRepo{}
+			app := fiber.New()
+			app.Post("/", New(repo).CreateSubscription)
+			req := httptest.NewRequest(fiber.MethodPost, "/", strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.status || resp.Header.Get(fiber.HeaderLocation) != tt.location {
+				t.Fatalf("response = %d Location %q, want %d Location %q",
+					resp.StatusCode, resp.Header.Get(fiber.HeaderLocation), tt.status, tt.location)
+			}
+			if tt.location == "" {
+				if len(repo.created) != 0 {
+					t.Errorf("invalid subscription was sent to repository")
+				}
+				return
+			}
+
+			// ответ содержит созданную запись с ее id
+			var sub subscriptions.Subscription
+			if err := json.NewDecoder(resp.Body).Decode(&sub); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if sub.ID != 1 || sub.ServiceName != "Yandex Plus" || resp.Header.Get(fiber.HeaderETag) != `"1"` {
+				t.Errorf("response = %+v, ETag %q", sub, resp.Header.Get(fiber.HeaderETag))
+			}
+		})
+	}
+}
+
+func TestCreateSubscriptionContext(t *testing.T) {
+	type ctxKey struct{}
+	repo := &stubRepo{}
+	app := fiber.New()
+	// контекст запроса, заданный middleware, доходит до хранилища
+	app.Use(func(c *fiber.Ctx) error {
+		c.SetUserContext(context.WithValue(c.UserContext(), ctxKey{}, "request"))
+		return c.Next()
+	})
+	app.Post("/", New(repo).CreateSubscription)
+
+	body := `{"service_name":"Yandex Plus","price":399,"user_id":"60601fee-2bf1-4721-ae6f-7636e79a0cba","start_date":"07-2025"}`
+	req := httptest.NewRequest(fiber.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != fiber.StatusCreated || repo.createCtx == nil || repo.createCtx.Value(ctxKey{}) != "request" {
+		t.Errorf("status = %d, repository context = %v", resp.StatusCode, repo.createCtx)
+	}
+}
+
+func TestRestoreSubscription(t *testing.T) {
+	// restore восстанавливает подписку 7 версии 2 и увеличивает версию
+	restore := func(id, expectedVersion int) (*subscriptions.Subscription, error) {
+		if id != 7 {
+			return nil, repository.ErrSubscriptionDoesNotExist
+		}
+		if expectedVersion != repository.AnyVersion && expectedVersion != 2 {
+			return nil, repository.ErrVersionMismatch
+		}
+		return &subscriptions.Subscription{ID: id, Version: 3}, nil
+	}
+	tests := []struct {
+		name    string
+		path    string
+		ifMatch string
+		status  int
+		etag    string
+	}{
+		{name: "restore", path: "/7/restore", status: fiber.StatusOK, etag: `"3"`},
+		{name: "matching version", path: "/7/restore", ifMatch: `"2"`, status: fiber.StatusOK, etag: `"3"`},
+		{name: "version mismatch", path: "/7/restore", ifMatch: `"1"`, status: fiber.StatusPreconditionFailed},
+		{name: "not found", path: "/8/restore", status: fiber.StatusNotFound},
+		{name: "wrong id", path: "/x/restore", status: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Post("/:id/restore", New(&stubRepo{restore: restore}).RestoreSubscription)
+			req := httptest.NewRequest(fiber.MethodPost, tt.path, nil)
+			if tt.ifMatch != "" {
+				req.Header.Set(fiber.HeaderIfMatch, tt.ifMatch)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status || resp.Header.Get(fiber.HeaderETag) != tt.etag {
+				t.Errorf("response = %d ETag %q, want %d ETag %q", resp.StatusCode, resp.Header.Get(fiber.HeaderETag), tt.status, tt.etag)
+			}
+		})
+	}
+}
+
+func TestParseAsOf(t *testing.T) {
+	tests := []struct {
+		query  string
+		asOf   *time.Time
+		status int
+	}{
+		{query: ""},
+		{query: "as_of=2025-03-01T10:00:00Z", asOf: ptr(time.Date(2025, time.March, 1, 10, 0, 0, 0, time.UTC))},
+		{query: "as_of=2025-03-01T13:00:00%2B03:00", asOf: ptr(time.Date(2025, time.March, 1, 10, 0, 0, 0, time.UTC))},
+		{query: "as_of=2025-03-01", status: fiber.StatusBadRequest},
+		{query: "as_of=03-2025", status: fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				asOf, err := parseAsOf(c)
+				if tt.status != 0 {
+					if apiErr := translateError(err); apiErr.Status != tt.status || len(apiErr.Details) == 0 || apiErr.Details[0].Field != "as_of" {
+						t.Errorf("parseAsOf error = %v", err)
+					}
+					return nil
+				}
+				if err != nil {
+					t.Errorf("parseAsOf: %v", err)
+					return nil
+				}
+				if (asOf == nil) != (tt.asOf == nil) || (asOf != nil && !asOf.Equal(*tt.asOf)) {
+					t.Errorf("parseAsOf = %v, want %v", asOf, tt.asOf)
+				}
+				return nil
+			})
+			if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/?"+tt.query, nil)); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/subscriptions_api/internal/importer"
+	"github.com/subscriptions_api/internal/logger"
+)
+
+// форматы отчета об импорте
+const (
+	importReportJSON = "json"
+	importReportCSV  = "csv"
+)
+
+// ImportSubscriptions godoc
+// @Summary Импортировать записи о подписках из файла
+// @Description Принимает файл CSV (заголовок service_name,price,user_id,start_date,end_date и необязательные колонки currency, service_id, billing_period, billing_months) или NDJSON (одна подписка в формате JSON на строку).
+// @Description Строки, не прошедшие валидацию, пропускаются и попадают в отчет с номером строки файла, остальные вставляются одной операцией.
+// @Description Формат определяется параметром format или заголовком Content-Type (text/csv, application/x-ndjson).
+// @Description С dry_run=true файл только проверяется, в БД ничего не записывается.
+// @Description Файл читается потоком по мере вставки строк и не ограничивается SERVER_BODY_LIMIT
+// @Tags Subscriptions
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Produce text/csv
+// @Param format query string false "Формат файла" Enums(csv, ndjson)
+// @Param dry_run query bool false "Только проверить файл"
+// @Param report query string false "Формат отчета: json (по умолчанию) или csv со списком ошибок" Enums(json, csv)
+// @Param file body string true "Содержимое файла"
+// @Success 200 {object} importer.Report
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/subscriptions/import [post]
+func (h *Handler) ImportSubscriptions(c *fiber.Ctx) error {
+	format := c.Query("format")
+	if format == "" {
+		format = importFormatOf(c.Get(fiber.HeaderContentType))
+	}
+	reportFormat := c.Query("report", importReportJSON)
+	if reportFormat != importReportJSON && reportFormat != importReportCSV {
+		return sendError(c, "failed parse report", badRequest("Неверный формат отчета", "report", nil))
+	}
+	dryRun := c.QueryBool("dry_run", false)
+
+	// сервер принимает тела запросов потоком (StreamRequestBody), поэтому файл не загружается в память целиком;
+	// без потока тело уже прочитано
+	var body io.Reader = c.Context().RequestBodyStream()
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+
+	report, err := importer.Run(c.UserContext(), h.repo, body, format, dryRun)
+	switch {
+	case errors.Is(err, importer.ErrWrongFormat):
+		return sendError(c, "failed parse format", badRequest("Неверный формат файла", "format", err))
+	case errors.Is(err, importer.ErrMissingHeader):
+		return sendError(c, "failed parse csv header", badRequest("В заголовке CSV нет обязательной колонки", "", err))
+	case err != nil:
+		return sendError(c, "failed ImportSubscriptions request", err)
+	}
+
+	logger.L.Info("success ImportSubscriptions request", "format", format, "dry_run", dryRun,
+		"total", report.Total, "imported", report.Imported, "failed", report.Failed)
+
+	if reportFormat == importReportCSV {
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="import-errors.csv"`)
+		c.Set("X-Import-Total", strconv.Itoa(report.Total))
+		c.Set("X-Import-Imported", strconv.FormatInt(report.Imported, 10))
+		c.Set("X-Import-Failed", strconv.Itoa(report.Failed))
+		return importer.WriteErrorsCSV(c.Response().BodyWriter(), report)
+	}
+	return c.Status(fiber.StatusOK).JSON(report)
+}
+
+// importFormatOf определяет формат файла импорта по заголовку Content-Type
+func importFormatOf(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.TrimSpace(strings.ToLower(mediaType)) {
+	case "text/csv":
+		return importer.FormatCSV
+	case "application/x-ndjson", "application/ndjson":
+		return importer.FormatNDJSON
+	}
+	return ""
+}
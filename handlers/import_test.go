@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/subscriptions_api/internal/importer"
+)
+
+func TestImportFormatOf(t *testing.T) {
+	tests := []struct {
+		contentType string
+		format      string
+	}{
+		{contentType: "text/csv", format: importer.FormatCSV},
+		{contentType: "Text/CSV; charset=utf-8", format: importer.FormatCSV},
+		{contentType: "application/x-ndjson", format: importer.FormatNDJSON},
+		{contentType: "application/ndjson", format: importer.FormatNDJSON},
+		{contentType: "application/json", format: ""},
+		{contentType: "", format: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			if got := importFormatOf(tt.contentType); got != tt.format {
+				t.Errorf("importFormatOf(%q) = %q, want %q", tt.contentType, got, tt.format)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LimitBody возвращает middleware, которое читает тело запроса в память, но не больше limit байт.
+// Сервер принимает тела потоком, чтобы импорт не загружал файл целиком;
+// для остальных маршрутов ограничение размера тела проверяется здесь
+func LimitBody(limit int) fiber.Handler {
+	if limit <= 0 {
+		limit = fiber.DefaultBodyLimit
+	}
+	return func(c *fiber.Ctx) error {
+		req := c.Request()
+		if !req.IsBodyStream() {
+			return c.Next()
+		}
+		if req.Header.ContentLength() > limit {
+			return bodyTooLarge(c)
+		}
+
+		// тело без Content-Length (chunked) дочитываем до limit + 1 байта, чтобы заметить превышение
+		body, err := io.ReadAll(io.LimitReader(req.BodyStream(), int64(limit)+1))
+		if err != nil {
+			return sendError(c, "failed read request body", badRequest("Не удалось прочитать тело запроса", "", err))
+		}
+		if len(body) > limit {
+			return bodyTooLarge(c)
+		}
+		req.SetBody(body)
+		return c.Next()
+	}
+}
+
+// bodyTooLarge отвечает 413 и закрывает соединение: непрочитанный остаток тела нельзя принять за следующий запрос
+func bodyTooLarge(c *fiber.Ctx) error {
+	c.Context().SetConnectionClose()
+	return sendError(c, "request body too large", &APIError{Status: fiber.StatusRequestEntityTooLarge, Code: CodeBadRequest,
+		Message: "Тело запроса превышает допустимый размер", Err: fiber.ErrRequestEntityTooLarge})
+}
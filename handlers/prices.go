@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/subscriptions_api/internal/logger"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+// GetPriceChanges godoc
+// @Summary Получить изменения цены подписки
+// @Description Возвращает цены подписки, действующие с указанных месяцев. До первого изменения действует цена подписки
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Success 200 {array} subscriptions.PriceChange
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/subscriptions/{id}/prices [get]
+func (h *Handler) GetPriceChanges(c *fiber.Ctx) error {
+
+	// провалидируем id
+	id, err := parseID(c)
+	if err != nil {
+		return sendError(c, "wrong id format", err)
+	}
+
+	// запрос к БД
+	changes, err := h.repo.GetPriceChanges(c.UserContext(), id)
+	if err != nil {
+		return sendError(c, "failed GetPriceChanges request", err)
+	}
+
+	// успешный ответ
+	logger.L.Info("success GetPriceChanges request", "prices", len(changes))
+	return c.Status(fiber.StatusOK).JSON(changes)
+}
+
+// SchedulePriceChange godoc
+// @Summary Запланировать изменение цены подписки
+// @Description Назначает подписке новую цену с будущего месяца в пределах ее активного периода; изменение на тот же месяц заменяется.
+// @Description Суммы за период и помесячная разбивка считают каждый месяц по действующей в нем цене
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param change body subscriptions.PriceChange true "Новая цена"
+// @Success 201 {object} subscriptions.PriceChange
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/subscriptions/{id}/prices [post]
+func (h *Handler) SchedulePriceChange(c *fiber.Ctx) error {
+
+	// провалидируем id
+	id, err := parseID(c)
+	if err != nil {
+		return sendError(c, "wrong id format", err)
+	}
+
+	var change subscriptions.PriceChange
+	// парсим JSON в изменение цены
+	if err := c.BodyParser(&change); err != nil {
+		return sendError(c, "failed parse price change", badRequest("Неверный формат данных", "", err))
+	}
+
+	// запрос к БД; месяц проверяется по датам подписки
+	if err := h.repo.SchedulePriceChange(c.UserContext(), id, &change); err != nil {
+		return sendError(c, "failed SchedulePriceChange request", err)
+	}
+
+	// успешный ответ
+	logger.L.Info("success SchedulePriceChange request", "month", change.Month)
+	return c.Status(fiber.StatusCreated).JSON(change)
+}
+
+// CancelPriceChange godoc
+// @Summary Отменить запланированное изменение цены подписки
+// @Description Удаляет изменение цены с будущего месяца; изменения с текущего и прошедших месяцев не отменяются
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param month path string true "Месяц изменения цены" format(MM-YYYY)
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/subscriptions/{id}/prices/{month} [delete]
+func (h *Handler) CancelPriceChange(c *fiber.Ctx) error {
+
+	// провалидируем id и месяц
+	id, err := parseID(c)
+	if err != nil {
+		return sendError(c, "wrong id format", err)
+	}
+	month, err := subscriptions.ParseMonth(c.Params("month"))
+	if err != nil {
+		return sendError(c, "wrong month format", badRequest("Неправильно указан формат месяца", "month", err))
+	}
+
+	// запрос к БД
+	if err := h.repo.CancelPriceChange(c.UserContext(), id, month); err != nil {
+		return sendError(c, "failed CancelPriceChange request", err)
+	}
+
+	// успешный ответ
+	logger.L.Info("success CancelPriceChange request", "month", month.String())
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/subscriptions_api/internal/logger"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+// GetRates godoc
+// @Summary Получить курсы валют
+// @Description Возвращает курсы валют к рублю. Курс действует с указанного месяца до следующего курса той же валюты
+// @Tags Rates
+// @Accept json
+// @Produce json
+// @Success 200 {array} subscriptions.Rate
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/rates [get]
+func (h *Handler) GetRates(c *fiber.Ctx) error {
+
+	// запрос к БД
+	rates, err := h.repo.GetRates(c.UserContext())
+	if err != nil {
+		return sendError(c, "failed GetRates request", err)
+	}
+
+	// успешный ответ
+	logger.L.Info("success GetRates request", "rates", len(rates))
+	return c.Status(fiber.StatusOK).JSON(rates)
+}
+
+// UpsertRates godoc
+// @Summary Загрузить курсы валют
+// @Description Добавляет курсы валют к рублю; курс валюты на уже известный месяц заменяется,
+// @Description а прежний курс сохраняется для подсчета сумм на момент as_of.
+// @Description Доступно только администраторам
+// @Tags Rates
+// @Accept json
+// @Produce json
+// @Param rates body []subscriptions.Rate true "Курсы валют"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/rates [put]
+func (h *Handler) UpsertRates(c *fiber.Ctx) error {
+	if err := requireAdmin(c, "Изменять курсы валют может только администратор"); err != nil {
+		return sendError(c, "forbidden UpsertRates request", err)
+	}
+
+	var rates []subscriptions.Rate
+	// парсим JSON в список курсов
+	if err := c.BodyParser(&rates); err != nil {
+		return sendError(c, "failed parse rates", badRequest("Неверный формат данных", "", err))
+	}
+	if len(rates) == 0 {
+		return sendError(c, "empty rates", badRequest("Нужно передать хотя бы один курс", "", nil))
+	}
+
+	// провалидируем полученные данные
+	for i := range rates {
+		if err := subscriptions.ValidateRate(&rates[i]); err != nil {
+			return sendError(c, "failed Validation rate", err)
+		}
+	}
+
+	// запрос к БД
+	if err := h.repo.UpsertRates(c.UserContext(), rates); err != nil {
+		return sendError(c, "failed UpsertRates request", err)
+	}
+
+	// успешный ответ
+	logger.L.Info("success UpsertRates request", "rates", len(rates))
+	return c.SendStatus(fiber.StatusNoContent)
+}
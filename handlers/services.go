@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/subscriptions_api/internal/logger"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+// adminOnlyServices - сообщение для клиентов без права менять справочник сервисов
+const adminOnlyServices = "Изменять справочник сервисов может только администратор"
+
+// GetAllServices godoc
+// @Summary Получить справочник сервисов
+// @Description Возвращает сервисы с каноническими названиями, категориями и синонимами
+// @Tags Services
+// @Accept json
+// @Produce json
+// @Success 200 {array} subscriptions.Service
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/services [get]
+func (h *Handler) GetAllServices(c *fiber.Ctx) error {
+
+	// запрос к БД
+	services, err := h.repo.GetAllServices(c.UserContext())
+	if err != nil {
+		return sendError(c, "failed GetAllServices request", err)
+	}
+
+	// успешный ответ
+	logger.L.Info("success GetAllServices request", "services", len(services))
+	return c.Status(fiber.StatusOK).JSON(services)
+}
+
+// GetService godoc
+// @Summary Получить сервис по ID
+// @Tags Services
+// @Accept json
+// @Produce json
+// @Param id path int true "ID сервиса"
+// @Success 200 {object} subscriptions.Service
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/services/{id} [get]
+func (h *Handler) GetService(c *fiber.Ctx) error {
+
+	// провалидируем id
+	id, err := parseID(c)
+	if err != nil {
+		return sendError(c, "wrong id format", err)
+	}
+
+	// запрос к БД
+	svc, err := h.repo.GetServiceById(c.UserContext(), id)
+	if err != nil {
+		return sendError(c, "failed GetService request", err)
+	}
+
+	// успешный ответ
+	logger.L.Info("success GetService request")
+	return c.Status(fiber.StatusOK).JSON(svc)
+}
+
+// CreateService godoc
+// @Summary Добавить сервис в справочник
+// @Description Название и синонимы сравниваются без учета регистра и лишних пробелов и не могут принадлежать другому сервису.
+// @Description Доступно только администраторам
+// @Tags Services
+// @Accept json
+// @Produce json
+// @Param service body subscriptions.Service true "Сервис"
+// @Success 201 {object} subscriptions.Service
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/services [post]
+func (h *Handler) CreateService(c *fiber.Ctx) error {
+	if err := requireAdmin(c, adminOnlyServices); err != nil {
+		return sendError(c, "forbidden CreateService request", err)
+	}
+
+	var svc subscriptions.Service
+	// парсим JSON в структуру service
+	if err := c.BodyParser(&svc); err != nil {
+		return sendError(c, "failed parse service", badRequest("Неверный формат данных", "", err))
+	}
+
+	// провалидируем полученные данные
+	if err := subscriptions.ValidateService(&svc); err != nil {
+		return sendError(c, "failed Validation service", err)
+	}
+
+	// запрос к БД
+	if err := h.repo.CreateService(c.UserContext(), &svc); err != nil {
+		return sendError(c, "failed CreateService request", err)
+	}
+
+	// успешный ответ
+	logger.L.Info("success CreateService request", "service_id", svc.ID)
+	return c.Status(fiber.StatusCreated).JSON(svc)
+}
+
+// UpdateService godoc
+// @Summary Изменить сервис
+// @Description Перезаписывает название, категорию и синонимы сервиса. Прежнее название остается синонимом,
+// @Description подписки сервиса получают новое название. Доступно только администраторам
+// @Tags Services
+// @Accept json
+// @Produce json
+// @Param id path int true "ID сервиса"
+// @Param service body subscriptions.Service true "Сервис"
+// @Success 200 {object} subscriptions.Service
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/services/{id} [put]
+func (h *Handler) UpdateService(c *fiber.Ctx) error {
+	if err := requireAdmin(c, adminOnlyServices); err != nil {
+		return sendError(c, "forbidden UpdateService request", err)
+	}
+
+	// провалидируем id
+	id, err := parseID(c)
+	if err != nil {
+		return sendError(c, "wrong id format", err)
+	}
+
+	var svc subscriptions.Service
+	// парсим JSON в структуру service
+	if err := c.BodyParser(&svc); err != nil {
+		return sendError(c, "failed parse service", badRequest("Неверный формат данных", "", err))
+	}
+
+	// провалидируем полученные данные
+	if err := subscriptions.ValidateService(&svc); err != nil {
+		return sendError(c, "failed Validation service", err)
+	}
+
+	// запрос к БД
+	if err := h.repo.UpdateServiceById(c.UserContext(), id, &svc); err != nil {
+		return sendError(c, "failed UpdateService request", err)
+	}
+
+	// успешный ответ
+	logger.L.Info("success UpdateService request", "service_id", id)
+	return c.Status(fiber.StatusOK).JSON(svc)
+}
+
+// DeleteService godoc
+// @Summary Удалить сервис
+// @Description Удаляет сервис вместе с синонимами. Сервис, на который ссылаются подписки, удалить нельзя - его можно слить с другим.
+// @Description Доступно только администраторам
+// @Tags Services
+// @Accept json
+// @Produce json
+// @Param id path int true "ID сервиса"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/services/{id} [delete]
+func (h *Handler) DeleteService(c *fiber.Ctx) error {
+	if err := requireAdmin(c, adminOnlyServices); err != nil {
+		return sendError(c, "forbidden DeleteService request", err)
+	}
+
+	// провалидируем id
+	id, err := parseID(c)
+	if err != nil {
+		return sendError(c, "wrong id format", err)
+	}
+
+	// запрос к БД
+	if err := h.repo.DeleteServiceById(c.UserContext(), id); err != nil {
+		return sendError(c, "failed DeleteService request", err)
+	}
+
+	// успешный ответ
+	logger.L.Info("success DeleteService request", "service_id", id)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// MergeServices godoc
+// @Summary Слить сервисы
+// @Description Сливает сервис from с сервисом id в одной транзакции: названия и синонимы from становятся синонимами id,
+// @Description подписки from получают id и его название (изменения попадают в журнал подписок), сервис from удаляется.
+// @Description Доступно только администраторам
+// @Tags Services
+// @Accept json
+// @Produce json
+// @Param id path int true "ID сервиса, который остается"
+// @Param merge body subscriptions.ServiceMerge true "Сервис, который сливается"
+// @Success 200 {object} subscriptions.Service
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /api/services/{id}/merge [post]
+func (h *Handler) MergeServices(c *fiber.Ctx) error {
+	if err := requireAdmin(c, adminOnlyServices); err != nil {
+		return sendError(c, "forbidden MergeServices request", err)
+	}
+
+	// провалидируем id
+	id, err := parseID(c)
+	if err != nil {
+		return sendError(c, "wrong id format", err)
+	}
+
+	var merge subscriptions.ServiceMerge
+	// парсим JSON в структуру merge
+	if err := c.BodyParser(&merge); err != nil {
+		return sendError(c, "failed parse merge", badRequest("Неверный формат данных", "", err))
+	}
+
+	// провалидируем полученные данные
+	if err := subscriptions.ValidateServiceMerge(id, &merge); err != nil {
+		return sendError(c, "failed Validation merge", err)
+	}
+
+	// запрос к БД
+	svc, err := h.repo.MergeServices(c.UserContext(), id, merge.From)
+	if err != nil {
+		return sendError(c, "failed MergeServices request", err)
+	}
+
+	// успешный ответ
+	logger.L.Info("success MergeServices request", "service_id", id, "from", merge.From)
+	return c.Status(fiber.StatusOK).JSON(svc)
+}
@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofrs/uuid"
+)
+
+var (
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrTokenExpired       = errors.New("token expired")
+	ErrInvalidAPIKey      = errors.New("invalid api key")
+	ErrForbidden          = errors.New("access denied")
+)
+
+// способы аутентификации
+const (
+	MethodJWT    = "jwt"
+	MethodAPIKey = "api_key"
+	// MethodSystem - внутренние операции сервиса: фоновые задачи и подкоманды CLI
+	MethodSystem = "system"
+)
+
+// роли клиентов: user видит только подписки своего user_id, admin - все
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// apiKeyPrefix отличает API ключи этого сервиса от других секретов
+const apiKeyPrefix = "sk_"
+
+// Principal - аутентифицированный клиент
+type Principal struct {
+	// Subject - sub из JWT или имя API ключа
+	Subject string
+	// UserID - пользователь, от имени которого действует клиент; uuid.Nil, если клиент не привязан к пользователю
+	UserID uuid.UUID
+	Role   string
+	Method string
+}
+
+// System возвращает клиента для внутренних операций сервиса с доступом ко всем подпискам
+func System(name string) *Principal {
+	return &Principal{Subject: name, Role: RoleAdmin, Method: MethodSystem}
+}
+
+// IsAdmin сообщает, есть ли у клиента доступ ко всем подпискам
+func (p *Principal) IsAdmin() bool {
+	return p.Role == RoleAdmin
+}
+
+// APIKey - сохраненный API ключ; сам ключ не хранится, только его хэш
+type APIKey struct {
+	ID     int
+	Name   string
+	UserID uuid.UUID
+	Role   string
+}
+
+// APIKeyStore ищет действующие API ключи
+type APIKeyStore interface {
+	// GetAPIKeyByHash возвращает ключ по хэшу или ErrInvalidAPIKey, если ключа нет или он отозван
+	GetAPIKeyByHash(ctx context.Context, hash string) (*APIKey, error)
+}
+
+// Authenticator проверяет JWT и API ключи
+type Authenticator struct {
+	jwt  *JWTVerifier
+	keys APIKeyStore
+}
+
+// New создает Authenticator; verifier == nil отключает JWT, keys == nil - API ключи
+func New(verifier *JWTVerifier, keys APIKeyStore) *Authenticator {
+	return &Authenticator{jwt: verifier, keys: keys}
+}
+
+// Authenticate проверяет значение заголовка Authorization (Bearer <jwt>) или API ключ.
+// Если переданы оба, используется JWT
+func (a *Authenticator) Authenticate(ctx context.Context, authorization, apiKey string) (*Principal, error) {
+	if authorization != "" {
+		scheme, token, ok := strings.Cut(authorization, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || a.jwt == nil {
+			return nil, fmt.Errorf("[Authenticate|scheme] %w", ErrInvalidToken)
+		}
+		claims, err := a.jwt.Verify(strings.TrimSpace(token))
+		if err != nil {
+			return nil, fmt.Errorf("[Authenticate] %w", err)
+		}
+		principal := &Principal{Subject: claims.Subject, Role: roleOf(claims.Role), Method: MethodJWT}
+		// sub в виде UUID - идентификатор пользователя
+		if userID, err := uuid.FromString(claims.Subject); err == nil {
+			principal.UserID = userID
+		}
+		return principal, nil
+	}
+
+	if apiKey != "" {
+		if a.keys == nil || !strings.HasPrefix(apiKey, apiKeyPrefix) {
+			return nil, fmt.Errorf("[Authenticate|api key] %w", ErrInvalidAPIKey)
+		}
+		key, err := a.keys.GetAPIKeyByHash(ctx, HashAPIKey(apiKey))
+		if err != nil {
+			return nil, fmt.Errorf("[Authenticate] %w", err)
+		}
+		return &Principal{Subject: key.Name, UserID: key.UserID, Role: roleOf(key.Role), Method: MethodAPIKey}, nil
+	}
+
+	return nil, fmt.Errorf("[Authenticate] %w", ErrMissingCredentials)
+}
+
+// roleOf возвращает роль клиента; все, кроме admin, получают права user
+func roleOf(role string) string {
+	if role == RoleAdmin {
+		return RoleAdmin
+	}
+	return RoleUser
+}
+
+// ValidRole сообщает, известна ли роль
+func ValidRole(role string) bool {
+	return role == RoleUser || role == RoleAdmin
+}
+
+// GenerateAPIKey создает новый случайный API ключ
+func GenerateAPIKey() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("[GenerateAPIKey] %w", err)
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// HashAPIKey возвращает хэш, под которым ключ хранится в БД.
+// Ключи случайные и длинные, поэтому соль и медленный хэш не нужны
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+type principalKey struct{}
+
+// WithPrincipal сохраняет клиента в контексте запроса
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext возвращает клиента, сохраненного в контексте
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
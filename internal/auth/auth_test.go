@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gofrs/uuid"
+)
+
+// keyStore - хранилище API ключей в памяти, ключи хранятся по хэшу
+type keyStore map[string]*APIKey
+
+func (s keyStore) GetAPIKeyByHash(_ context.Context, hash string) (*APIKey, error) {
+	key, ok := s[hash]
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	return key, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	secret := []byte("test-secret")
+	verifier := newTestVerifier(t, JWTConfig{HS256Secret: string(secret)})
+	userID := uuid.Must(uuid.FromString("60601fee-2bf1-4721-ae6f-7636e79a0cba"))
+
+	apiKey, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := keyStore{HashAPIKey(apiKey): {ID: 1, Name: "billing", UserID: userID, Role: RoleUser}}
+
+	adminClaims := validClaims()
+	adminClaims["sub"], adminClaims["role"] = "cron", RoleAdmin
+	userToken := signToken(t, map[string]any{"alg": AlgHS256}, validClaims(), hs256(secret))
+	adminToken := signToken(t, map[string]any{"alg": AlgHS256}, adminClaims, hs256(secret))
+
+	tests := []struct {
+		name          string
+		authenticator *Authenticator
+		authorization string
+		apiKey        string
+		want          *Principal
+		err           error
+	}{
+		{
+			name: "jwt user", authenticator: New(verifier, keys), authorization: "Bearer " + userToken,
+			want: &Principal{Subject: userID.String(), UserID: userID, Role: RoleUser, Method: MethodJWT},
+		},
+		{
+			name: "jwt admin without user id", authenticator: New(verifier, keys), authorization: "bearer " + adminToken,
+			want: &Principal{Subject: "cron", Role: RoleAdmin, Method: MethodJWT},
+		},
+		{
+			name: "jwt preferred over api key", authenticator: New(verifier, keys), authorization: "Bearer " + adminToken, apiKey: apiKey,
+			want: &Principal{Subject: "cron", Role: RoleAdmin, Method: MethodJWT},
+		},
+		{
+			name: "api key", authenticator: New(verifier, keys), apiKey: apiKey,
+			want: &Principal{Subject: "billing", UserID: userID, Role: RoleUser, Method: MethodAPIKey},
+		},
+		{name: "wrong scheme", authenticator: New(verifier, keys), authorization: "Basic " + userToken, err: ErrInvalidToken},
+		{name: "jwt disabled", authenticator: New(nil, keys), authorization: "Bearer " + userToken, err: ErrInvalidToken},
+		{name: "invalid jwt", authenticator: New(verifier, keys), authorization: "Bearer " + userToken + "x", err: ErrInvalidToken},
+		{name: "unknown api key", authenticator: New(verifier, keys), apiKey: apiKeyPrefix + "unknown", err: ErrInvalidAPIKey},
+		{name: "api key without prefix", authenticator: New(verifier, keys), apiKey: strings.TrimPrefix(apiKey, apiKeyPrefix), err: ErrInvalidAPIKey},
+		{name: "api keys disabled", authenticator: New(verifier, nil), apiKey: apiKey, err: ErrInvalidAPIKey},
+		{name: "no credentials", authenticator: New(verifier, keys), err: ErrMissingCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := tt.authenticator.Authenticate(context.Background(), tt.authorization, tt.apiKey)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Authenticate error = %v, want %v", err, tt.err)
+			}
+			if tt.want != nil && *principal != *tt.want {
+				t.Errorf("Authenticate = %+v, want %+v", principal, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoleOf(t *testing.T) {
+	tests := []struct {
+		role string
+		want string
+	}{
+		{role: RoleAdmin, want: RoleAdmin},
+		{role: RoleUser, want: RoleUser},
+		{role: "", want: RoleUser},
+		{role: "ADMIN", want: RoleUser},
+	}
+	for _, tt := range tests {
+		if got := roleOf(tt.role); got != tt.want {
+			t.Errorf("roleOf(%q) = %q, want %q", tt.role, got, tt.want)
+		}
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	first, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(first, apiKeyPrefix) || first == second {
+		t.Errorf("GenerateAPIKey = %q, %q", first, second)
+	}
+	if HashAPIKey(first) != HashAPIKey(first) || HashAPIKey(first) == HashAPIKey(second) || len(HashAPIKey(first)) != 64 {
+		t.Errorf("HashAPIKey(%q) = %q", first, HashAPIKey(first))
+	}
+}
+
+func TestPrincipalContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("FromContext found principal in empty context")
+	}
+	system := System("cli")
+	p, ok := FromContext(WithPrincipal(context.Background(), system))
+	if !ok || p != system || !p.IsAdmin() || p.Method != MethodSystem {
+		t.Errorf("FromContext = %+v, %v", p, ok)
+	}
+}
@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// поддерживаемые алгоритмы подписи JWT
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+)
+
+// JWTConfig - источники ключей и требования к токенам
+type JWTConfig struct {
+	// HS256Secret - общий секрет для HS256
+	HS256Secret string
+	// RS256PublicKeyFile - путь к публичному ключу RSA в формате PEM
+	RS256PublicKeyFile string
+	// JWKSFile - путь к локальному файлу JWKS
+	JWKSFile string
+	// Issuer и Audience проверяются, если заданы
+	Issuer   string
+	Audience string
+	// Leeway - допустимое расхождение часов при проверке exp и nbf
+	Leeway time.Duration
+}
+
+// verificationKey - ключ проверки подписи; alg фиксирован, чтобы токен не мог выбрать алгоритм сам
+type verificationKey struct {
+	kid    string
+	alg    string
+	secret []byte
+	public *rsa.PublicKey
+}
+
+// JWTVerifier проверяет подпись и стандартные claims токенов
+type JWTVerifier struct {
+	keys     []verificationKey
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+// NewJWTVerifier загружает ключи из конфига. Если ни один ключ не задан, возвращает nil:
+// JWT не принимаются, остаются только API ключи
+func NewJWTVerifier(cfg JWTConfig) (*JWTVerifier, error) {
+	v := &JWTVerifier{issuer: cfg.Issuer, audience: cfg.Audience, leeway: cfg.Leeway, now: time.Now}
+
+	if cfg.HS256Secret != "" {
+		v.keys = append(v.keys, verificationKey{alg: AlgHS256, secret: []byte(cfg.HS256Secret)})
+	}
+	if cfg.RS256PublicKeyFile != "" {
+		public, err := loadRSAPublicKey(cfg.RS256PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("[NewJWTVerifier] %w", err)
+		}
+		v.keys = append(v.keys, verificationKey{alg: AlgRS256, public: public})
+	}
+	if cfg.JWKSFile != "" {
+		keys, err := loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("[NewJWTVerifier] %w", err)
+		}
+		v.keys = append(v.keys, keys...)
+	}
+
+	if len(v.keys) == 0 {
+		return nil, nil
+	}
+	return v, nil
+}
+
+// Claims - проверяемые поля токена
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Role      string   `json:"role"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+}
+
+// audience - claim aud, который может быть строкой или массивом строк
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify проверяет подпись токена и claims exp, nbf, iss, aud. exp обязателен
+func (v *JWTVerifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("[Verify|format] %w", ErrInvalidToken)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("[Verify|header] %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("[Verify|signature] %w", errors.Join(ErrInvalidToken, err))
+	}
+	if !v.verifySignature(header, parts[0]+"."+parts[1], signature) {
+		return nil, fmt.Errorf("[Verify|signature] %w", ErrInvalidToken)
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("[Verify|claims] %w", err)
+	}
+	if err := v.validateClaims(&claims); err != nil {
+		return nil, fmt.Errorf("[Verify] %w", err)
+	}
+	return &claims, nil
+}
+
+// verifySignature проверяет подпись ключами с алгоритмом из заголовка и подходящим kid
+func (v *JWTVerifier) verifySignature(header jwtHeader, signed string, signature []byte) bool {
+	digest := sha256.Sum256([]byte(signed))
+	for _, key := range v.keys {
+		if key.alg != header.Alg || (header.Kid != "" && key.kid != "" && key.kid != header.Kid) {
+			continue
+		}
+		switch key.alg {
+		case AlgHS256:
+			mac := hmac.New(sha256.New, key.secret)
+			mac.Write([]byte(signed))
+			if hmac.Equal(mac.Sum(nil), signature) {
+				return true
+			}
+		case AlgRS256:
+			if rsa.VerifyPKCS1v15(key.public, crypto.SHA256, digest[:], signature) == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (v *JWTVerifier) validateClaims(claims *Claims) error {
+	now := v.now()
+	if claims.ExpiresAt == nil {
+		return fmt.Errorf("[validateClaims|exp] %w", ErrInvalidToken)
+	}
+	if now.After(numericDate(*claims.ExpiresAt).Add(v.leeway)) {
+		return fmt.Errorf("[validateClaims|exp] %w", ErrTokenExpired)
+	}
+	if claims.NotBefore != nil && now.Before(numericDate(*claims.NotBefore).Add(-v.leeway)) {
+		return fmt.Errorf("[validateClaims|nbf] %w", ErrInvalidToken)
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return fmt.Errorf("[validateClaims|iss] %w", ErrInvalidToken)
+	}
+	if v.audience != "" && !containsString(claims.Audience, v.audience) {
+		return fmt.Errorf("[validateClaims|aud] %w", ErrInvalidToken)
+	}
+	return nil
+}
+
+// decodeSegment декодирует часть токена из base64url JSON
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.Join(ErrInvalidToken, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.Join(ErrInvalidToken, err)
+	}
+	return nil
+}
+
+// numericDate переводит NumericDate JWT (секунды, возможно дробные) во время
+func numericDate(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
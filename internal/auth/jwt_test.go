@@ -0,0 +1,269 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testNow - момент, на который проверяются токены в тестах
+var testNow = time.Date(2025, time.July, 1, 12, 0, 0, 0, time.UTC)
+
+// signToken собирает JWT с заголовком header и claims; sign подписывает "header.claims"
+func signToken(t *testing.T, header, claims map[string]any, sign func(signed []byte) []byte) string {
+	t.Helper()
+	segment := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := segment(header) + "." + segment(claims)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+func hs256(secret []byte) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signed)
+		return mac.Sum(nil)
+	}
+}
+
+func rs256(t *testing.T, key *rsa.PrivateKey) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signature
+	}
+}
+
+// validClaims возвращает claims токена, действующего в момент testNow
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub": "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+		"exp": testNow.Add(time.Hour).Unix(),
+	}
+}
+
+// writeFile записывает данные во временный файл теста и возвращает путь к нему
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newTestVerifier(t *testing.T, cfg JWTConfig) *JWTVerifier {
+	t.Helper()
+	v, err := NewJWTVerifier(cfg)
+	if err != nil {
+		t.Fatalf("NewJWTVerifier: %v", err)
+	}
+	if v == nil {
+		t.Fatal("NewJWTVerifier returned nil verifier")
+	}
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+func generateRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestNewJWTVerifierWithoutKeys(t *testing.T) {
+	v, err := NewJWTVerifier(JWTConfig{Issuer: "issuer"})
+	if err != nil || v != nil {
+		t.Errorf("NewJWTVerifier = %v, %v; want nil, nil", v, err)
+	}
+}
+
+func TestVerifyHS256(t *testing.T) {
+	secret := []byte("test-secret")
+	v := newTestVerifier(t, JWTConfig{HS256Secret: string(secret), Issuer: "auth", Audience: "subscriptions", Leeway: time.Minute})
+	header := map[string]any{"alg": AlgHS256, "typ": "JWT"}
+
+	claimsWith := func(changes map[string]any) map[string]any {
+		claims := validClaims()
+		claims["iss"], claims["aud"], claims["role"] = "auth", "subscriptions", RoleAdmin
+		for name, value := range changes {
+			if value == nil {
+				delete(claims, name)
+				continue
+			}
+			claims[name] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{name: "valid", token: signToken(t, header, claimsWith(nil), hs256(secret))},
+		{name: "audience array", token: signToken(t, header, claimsWith(map[string]any{"aud": []string{"other", "subscriptions"}}), hs256(secret))},
+		{name: "expired within leeway", token: signToken(t, header, claimsWith(map[string]any{"exp": testNow.Add(-30 * time.Second).Unix()}), hs256(secret))},
+		{name: "expired", token: signToken(t, header, claimsWith(map[string]any{"exp": testNow.Add(-2 * time.Minute).Unix()}), hs256(secret)), err: ErrTokenExpired},
+		{name: "without exp", token: signToken(t, header, claimsWith(map[string]any{"exp": nil}), hs256(secret)), err: ErrInvalidToken},
+		{name: "not yet valid", token: signToken(t, header, claimsWith(map[string]any{"nbf": testNow.Add(time.Hour).Unix()}), hs256(secret)), err: ErrInvalidToken},
+		{name: "wrong issuer", token: signToken(t, header, claimsWith(map[string]any{"iss": "other"}), hs256(secret)), err: ErrInvalidToken},
+		{name: "wrong audience", token: signToken(t, header, claimsWith(map[string]any{"aud": []string{"other"}}), hs256(secret)), err: ErrInvalidToken},
+		{name: "wrong secret", token: signToken(t, header, claimsWith(nil), hs256([]byte("other"))), err: ErrInvalidToken},
+		{name: "alg none", token: signToken(t, map[string]any{"alg": "none"}, claimsWith(nil), func([]byte) []byte { return nil }), err: ErrInvalidToken},
+		{name: "two segments", token: "a.b", err: ErrInvalidToken},
+		{name: "broken header", token: "!!!.e30.c2ln", err: ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := v.Verify(tt.token)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Verify error = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && (claims.Subject != "60601fee-2bf1-4721-ae6f-7636e79a0cba" || claims.Role != RoleAdmin) {
+				t.Errorf("claims = %+v", claims)
+			}
+		})
+	}
+}
+
+func TestVerifyRS256(t *testing.T) {
+	key := generateRSAKey(t)
+	pkix, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"PUBLIC KEY":     pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix}),
+		"RSA PUBLIC KEY": pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)}),
+	}
+	header := map[string]any{"alg": AlgRS256}
+
+	for name, data := range files {
+		t.Run(name, func(t *testing.T) {
+			v := newTestVerifier(t, JWTConfig{RS256PublicKeyFile: writeFile(t, "key.pem", data)})
+			if _, err := v.Verify(signToken(t, header, validClaims(), rs256(t, key))); err != nil {
+				t.Errorf("Verify: %v", err)
+			}
+			other := generateRSAKey(t)
+			if _, err := v.Verify(signToken(t, header, validClaims(), rs256(t, other))); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify with other key error = %v, want %v", err, ErrInvalidToken)
+			}
+			// HS256 токен, подписанный публичным ключом как секретом, не принимается
+			if _, err := v.Verify(signToken(t, map[string]any{"alg": AlgHS256}, validClaims(), hs256(data))); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify alg confusion error = %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+}
+
+func TestNewJWTVerifierWrongKeyFile(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  func(t *testing.T) JWTConfig
+		err  error
+	}{
+		{
+			name: "not pem",
+			cfg: func(t *testing.T) JWTConfig {
+				return JWTConfig{RS256PublicKeyFile: writeFile(t, "key.pem", []byte("key"))}
+			},
+			err: ErrWrongKey,
+		},
+		{
+			name: "missing file",
+			cfg: func(t *testing.T) JWTConfig {
+				return JWTConfig{RS256PublicKeyFile: filepath.Join(t.TempDir(), "key.pem")}
+			},
+			err: os.ErrNotExist,
+		},
+		{
+			name: "unsupported jwk",
+			cfg: func(t *testing.T) JWTConfig {
+				return JWTConfig{JWKSFile: writeFile(t, "jwks.json", []byte(`{"keys":[{"kty":"EC","kid":"ec"}]}`))}
+			},
+			err: ErrWrongKey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewJWTVerifier(tt.cfg(t)); !errors.Is(err, tt.err) {
+				t.Errorf("NewJWTVerifier error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestVerifyJWKS(t *testing.T) {
+	first, second := generateRSAKey(t), generateRSAKey(t)
+	secret := []byte("jwks-secret")
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	rsaJWK := func(kid, use string, key *rsa.PrivateKey) map[string]any {
+		return map[string]any{"kty": "RSA", "kid": kid, "use": use, "alg": AlgRS256,
+			"n": encode(key.N.Bytes()), "e": encode(big.NewInt(int64(key.E)).Bytes())}
+	}
+	jwks, err := json.Marshal(map[string]any{"keys": []any{
+		rsaJWK("first", "sig", first),
+		rsaJWK("second", "", second),
+		map[string]any{"kty": "oct", "kid": "shared", "k": encode(secret)},
+		// ключ шифрования пропускается, даже если его тип не поддерживается
+		map[string]any{"kty": "EC", "kid": "enc", "use": "enc"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := newTestVerifier(t, JWTConfig{JWKSFile: writeFile(t, "jwks.json", jwks)})
+
+	tests := []struct {
+		name   string
+		header map[string]any
+		sign   func([]byte) []byte
+		err    error
+	}{
+		{name: "first kid", header: map[string]any{"alg": AlgRS256, "kid": "first"}, sign: rs256(t, first)},
+		{name: "second kid", header: map[string]any{"alg": AlgRS256, "kid": "second"}, sign: rs256(t, second)},
+		{name: "without kid", header: map[string]any{"alg": AlgRS256}, sign: rs256(t, second)},
+		{name: "oct key", header: map[string]any{"alg": AlgHS256, "kid": "shared"}, sign: hs256(secret)},
+		{name: "wrong kid", header: map[string]any{"alg": AlgRS256, "kid": "first"}, sign: rs256(t, second), err: ErrInvalidToken},
+		{name: "unknown kid", header: map[string]any{"alg": AlgRS256, "kid": "third"}, sign: rs256(t, first), err: ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := v.Verify(signToken(t, tt.header, validClaims(), tt.sign)); !errors.Is(err, tt.err) {
+				t.Errorf("Verify error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestNumericDate(t *testing.T) {
+	if got := numericDate(1751371200.5); !got.Equal(time.Unix(1751371200, int64(time.Second/2))) {
+		t.Errorf("numericDate = %v", got)
+	}
+	if got := numericDate(0); got.Unix() != 0 {
+		t.Errorf("numericDate(0) = %v", got)
+	}
+}
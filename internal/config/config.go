@@ -2,6 +2,7 @@ package config
 
 import (
 	"log"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 	"github.com/joho/godotenv"
@@ -10,6 +11,8 @@ import (
 type Config struct {
 	Server struct {
 		Port string `env:"SERVER_PORT" envDefault:":3000"`
+		// максимальный размер тела запроса в байтах; файл импорта читается потоком и не ограничивается
+		BodyLimit int `env:"SERVER_BODY_LIMIT" envDefault:"33554432"`
 	}
 
 	Storage struct {
@@ -18,6 +21,45 @@ type Config struct {
 		User     string `env:"DB_USER,required"`
 		Password string `env:"DB_PASSWORD,required"`
 		Name     string `env:"DB_NAME,required"`
+
+		// параметры пула соединений
+		MaxConns          int32         `env:"DB_MAX_CONNS" envDefault:"10"`
+		MinConns          int32         `env:"DB_MIN_CONNS" envDefault:"0"`
+		MaxConnLifetime   time.Duration `env:"DB_MAX_CONN_LIFETIME" envDefault:"1h"`
+		MaxConnIdleTime   time.Duration `env:"DB_MAX_CONN_IDLE_TIME" envDefault:"30m"`
+		HealthCheckPeriod time.Duration `env:"DB_HEALTH_CHECK_PERIOD" envDefault:"1m"`
+
+		// таймауты запросов к БД по типам операций
+		ReadTimeout      time.Duration `env:"DB_READ_TIMEOUT" envDefault:"3s"`
+		WriteTimeout     time.Duration `env:"DB_WRITE_TIMEOUT" envDefault:"5s"`
+		AggregateTimeout time.Duration `env:"DB_AGGREGATE_TIMEOUT" envDefault:"15s"`
+		BulkTimeout      time.Duration `env:"DB_BULK_TIMEOUT" envDefault:"5m"`
+	}
+
+	Retention struct {
+		// срок хранения удаленных подписок; 0 - хранить бессрочно
+		Period time.Duration `env:"SOFT_DELETE_RETENTION" envDefault:"720h"`
+		// как часто запускается очистка; 0 - очистка не запускается
+		Interval time.Duration `env:"SOFT_DELETE_PURGE_INTERVAL" envDefault:"1h"`
+	}
+
+	Money struct {
+		// как понимать числа в JSON: major - в единицах валюты (400 - 400 рублей), minor - в копейках/центах
+		JSONNumbers string `env:"MONEY_JSON_NUMBERS" envDefault:"major"`
+	}
+
+	Auth struct {
+		// с AUTH_ENABLED=false API доступен без аутентификации; включать после выпуска API ключа или настройки ключей JWT
+		Enabled bool `env:"AUTH_ENABLED" envDefault:"false"`
+
+		// ключи проверки JWT; без них принимаются только API ключи
+		JWTSecret        string `env:"AUTH_JWT_HS256_SECRET"`
+		JWTPublicKeyFile string `env:"AUTH_JWT_RS256_PUBLIC_KEY_FILE"`
+		JWKSFile         string `env:"AUTH_JWKS_FILE"`
+
+		JWTIssuer   string        `env:"AUTH_JWT_ISSUER"`
+		JWTAudience string        `env:"AUTH_JWT_AUDIENCE"`
+		JWTLeeway   time.Duration `env:"AUTH_JWT_LEEWAY" envDefault:"30s"`
 	}
 }
 
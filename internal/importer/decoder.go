@@ -0,0 +1,173 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+// rowError - ошибка разбора одной строки файла, после которой чтение можно продолжить
+type rowError struct {
+	field string
+	err   error
+}
+
+func (e *rowError) Error() string {
+	return e.err.Error()
+}
+
+func (e *rowError) Unwrap() error {
+	return e.err
+}
+
+// decoder последовательно читает подписки из файла импорта
+type decoder interface {
+	// Next возвращает номер строки файла и подписку; io.EOF - конец файла
+	Next() (int, *subscriptions.Subscription, error)
+}
+
+func newDecoder(format string, r io.Reader) (decoder, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVDecoder(r)
+	case FormatNDJSON:
+		return newNDJSONDecoder(r), nil
+	}
+	return nil, fmt.Errorf("[newDecoder] %w", ErrWrongFormat)
+}
+
+// requiredColumns - колонки CSV, без которых строку нельзя превратить в подписку;
+// service_name может быть пустым, если задан service_id
+var requiredColumns = []string{"service_name", "price", "user_id", "start_date"}
+
+// csvDecoder сопоставляет колонки CSV полям подписки по заголовку файла
+type csvDecoder struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("[newCSVDecoder] %w", ErrMissingHeader)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("[newCSVDecoder|read header] %w", err)
+	}
+
+	// заголовки сравниваются без учета регистра, пробелы и дефисы считаются подчеркиваниями
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		name = strings.NewReplacer(" ", "_", "-", "_").Replace(strings.TrimPrefix(name, "\ufeff"))
+		columns[name] = i
+	}
+	for _, name := range requiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("[newCSVDecoder|column %s] %w", name, ErrMissingHeader)
+		}
+	}
+	// строки с другим числом колонок, чем в заголовке, считаются ошибочными
+	cr.FieldsPerRecord = len(header)
+
+	return &csvDecoder{r: cr, columns: columns}, nil
+}
+
+func (d *csvDecoder) Next() (int, *subscriptions.Subscription, error) {
+	record, err := d.r.Read()
+	if errors.Is(err, io.EOF) {
+		return 0, nil, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr.StartLine, nil, &rowError{err: err}
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("[csvDecoder|read] %w", err)
+	}
+	line, _ := d.r.FieldPos(0)
+
+	value := func(name string) string {
+		i, ok := d.columns[name]
+		if !ok {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	sub := &subscriptions.Subscription{
+		ServiceName: value("service_name"),
+		Currency:    value("currency"),
+		// пустой период списания при валидации заменяется на monthly
+		BillingPeriod: value("billing_period"),
+		StartDate:     value("start_date"),
+	}
+	if serviceID := value("service_id"); serviceID != "" {
+		if sub.ServiceID, err = strconv.Atoi(serviceID); err != nil {
+			return line, nil, &rowError{field: "service_id", err: err}
+		}
+	}
+	if billingMonths := value("billing_months"); billingMonths != "" {
+		if sub.BillingMonths, err = strconv.Atoi(billingMonths); err != nil {
+			return line, nil, &rowError{field: "billing_months", err: err}
+		}
+	}
+	if sub.Price, err = subscriptions.ParseAmount(value("price")); err != nil {
+		return line, nil, &rowError{field: "price", err: err}
+	}
+	if sub.UserID, err = uuid.FromString(value("user_id")); err != nil {
+		return line, nil, &rowError{field: "user_id", err: err}
+	}
+	if endDate := value("end_date"); endDate != "" {
+		sub.EndDate = &endDate
+	}
+	return line, sub, nil
+}
+
+// ndjsonDecoder читает по одной подписке в формате JSON на строку
+type ndjsonDecoder struct {
+	s    *bufio.Scanner
+	line int
+}
+
+// maxNDJSONLine ограничивает длину одной строки NDJSON
+const maxNDJSONLine = 1 << 20
+
+func newNDJSONDecoder(r io.Reader) *ndjsonDecoder {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxNDJSONLine)
+	return &ndjsonDecoder{s: s}
+}
+
+func (d *ndjsonDecoder) Next() (int, *subscriptions.Subscription, error) {
+	for d.s.Scan() {
+		d.line++
+		line := strings.TrimSpace(d.s.Text())
+		// пустые строки пропускаем
+		if line == "" {
+			continue
+		}
+
+		var sub subscriptions.Subscription
+		if err := json.Unmarshal([]byte(line), &sub); err != nil {
+			return d.line, nil, &rowError{err: err}
+		}
+		return d.line, &sub, nil
+	}
+	if err := d.s.Err(); err != nil {
+		return d.line + 1, nil, fmt.Errorf("[ndjsonDecoder|scan] %w", err)
+	}
+	return 0, nil, io.EOF
+}
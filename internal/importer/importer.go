@@ -0,0 +1,142 @@
+package importer
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/subscriptions_api/subscriptions"
+)
+
+// форматы файлов импорта
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// MaxReportErrors ограничивает число ошибок, попадающих в отчет
+const MaxReportErrors = 1000
+
+var (
+	ErrWrongFormat   = errors.New("wrong import format")
+	ErrMissingHeader = errors.New("csv header is missing a required column")
+)
+
+// Store - хранилище, в которое выполняется импорт
+type Store interface {
+	// ImportSubscriptions вставляет подписки, которые возвращает next, пока он не вернет nil
+	ImportSubscriptions(ctx context.Context, next func() (*subscriptions.Subscription, error)) (int64, error)
+}
+
+// RowError описывает ошибку в строке файла импорта
+// @Description Ошибка в строке файла импорта
+type RowError struct {
+	Row     int    `json:"row" example:"3"`
+	Field   string `json:"field,omitempty" example:"price"`
+	Message string `json:"message" example:"wrong price"`
+}
+
+// Report - итог импорта
+// @Description Итог импорта подписок
+type Report struct {
+	Format          string     `json:"format" example:"csv"`
+	DryRun          bool       `json:"dry_run"`
+	Total           int        `json:"total"`
+	Valid           int        `json:"valid"`
+	Imported        int64      `json:"imported"`
+	Failed          int        `json:"failed"`
+	Errors          []RowError `json:"errors"`
+	ErrorsTruncated bool       `json:"errors_truncated,omitempty"`
+}
+
+func (r *Report) addError(row int, err error) {
+	r.Failed++
+	if len(r.Errors) >= MaxReportErrors {
+		r.ErrorsTruncated = true
+		return
+	}
+
+	rowErr := RowError{Row: row, Message: err.Error()}
+	var fieldErr *subscriptions.FieldError
+	var parseErr *rowError
+	switch {
+	case errors.As(err, &fieldErr):
+		rowErr.Field, rowErr.Message = fieldErr.Field, fieldErr.Err.Error()
+	case errors.As(err, &parseErr):
+		rowErr.Field = parseErr.field
+	}
+	r.Errors = append(r.Errors, rowErr)
+}
+
+// Run читает подписки из r, валидирует каждую строку и вставляет прошедшие валидацию в store.
+// Ошибочные строки попадают в отчет и не прерывают импорт; в режиме dryRun store не вызывается
+func Run(ctx context.Context, store Store, r io.Reader, format string, dryRun bool) (*Report, error) {
+	dec, err := newDecoder(format, r)
+	if err != nil {
+		return nil, fmt.Errorf("[Run] %w", err)
+	}
+
+	report := &Report{Format: format, DryRun: dryRun, Errors: []RowError{}}
+	next := func() (*subscriptions.Subscription, error) {
+		for {
+			row, sub, err := dec.Next()
+			if errors.Is(err, io.EOF) {
+				return nil, nil
+			}
+			var parseErr *rowError
+			if errors.As(err, &parseErr) {
+				report.Total++
+				report.addError(row, err)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			report.Total++
+			if err := subscriptions.Validate(sub); err != nil {
+				report.addError(row, err)
+				continue
+			}
+			report.Valid++
+			return sub, nil
+		}
+	}
+
+	if dryRun {
+		for {
+			sub, err := next()
+			if err != nil {
+				return nil, fmt.Errorf("[Run|read] %w", err)
+			}
+			if sub == nil {
+				return report, nil
+			}
+		}
+	}
+
+	imported, err := store.ImportSubscriptions(ctx, next)
+	if err != nil {
+		return nil, fmt.Errorf("[Run|import] %w", err)
+	}
+	report.Imported = imported
+	return report, nil
+}
+
+// WriteErrorsCSV записывает ошибки отчета в формате CSV: row,field,message
+func WriteErrorsCSV(w io.Writer, report *Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"row", "field", "message"}); err != nil {
+		return err
+	}
+	for _, rowErr := range report.Errors {
+		if err := cw.Write([]string{strconv.Itoa(rowErr.Row), rowErr.Field, rowErr.Message}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
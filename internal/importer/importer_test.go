@@ -0,0 +1,169 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/subscriptions_api/subscriptions"
+)
+
+const testUserID = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+
+// memoryStore собирает подписки, которые импорт передает в хранилище
+type memoryStore struct {
+	subs []*subscriptions.Subscription
+}
+
+func (s *memoryStore) ImportSubscriptions(_ context.Context, next func() (*subscriptions.Subscription, error)) (int64, error) {
+	for {
+		sub, err := next()
+		if err != nil {
+			return 0, err
+		}
+		if sub == nil {
+			return int64(len(s.subs)), nil
+		}
+		s.subs = append(s.subs, sub)
+	}
+}
+
+func TestRunCSV(t *testing.T) {
+	file := "\ufeffService Name, Price ,USER-ID,start date,end_date,currency,billing_period\n" +
+		"Yandex Plus,399.99," + testUserID + ",07-2025,,,\n" +
+		"Netflix,abc," + testUserID + ",07-2025,,,\n" +
+		"Spotify,199,not-a-uuid,07-2025,,,\n" +
+		"Okko,299," + testUserID + ",13-2025,,,\n" +
+		"Kion,1\n" +
+		"Ivi,99," + testUserID + ",01-2025,12-2025,USD,yearly\n"
+
+	store := &memoryStore{}
+	report, err := Run(context.Background(), store, strings.NewReader(file), FormatCSV, false)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.Total != 6 || report.Valid != 2 || report.Failed != 4 || report.Imported != 2 {
+		t.Errorf("report = total %d, valid %d, failed %d, imported %d; want 6, 2, 4, 2",
+			report.Total, report.Valid, report.Failed, report.Imported)
+	}
+
+	wantErrors := []RowError{
+		{Row: 3, Field: "price"},
+		{Row: 4, Field: "user_id"},
+		{Row: 5, Field: "start_date"},
+		{Row: 6},
+	}
+	if len(report.Errors) != len(wantErrors) {
+		t.Fatalf("errors = %+v, want %d errors", report.Errors, len(wantErrors))
+	}
+	for i, want := range wantErrors {
+		got := report.Errors[i]
+		if got.Row != want.Row || got.Field != want.Field || got.Message == "" {
+			t.Errorf("errors[%d] = %+v, want row %d field %q", i, got, want.Row, want.Field)
+		}
+	}
+
+	if len(store.subs) != 2 {
+		t.Fatalf("imported %d subscriptions, want 2", len(store.subs))
+	}
+	first, second := store.subs[0], store.subs[1]
+	if first.ServiceName != "Yandex Plus" || first.Price != 39999 || first.UserID.String() != testUserID ||
+		first.EndDate != nil || first.Currency != subscriptions.DefaultCurrency || first.BillingPeriod != subscriptions.BillingMonthly {
+		t.Errorf("first subscription = %+v", first)
+	}
+	if second.ServiceName != "Ivi" || second.Price != 9900 || second.EndDate == nil || *second.EndDate != "12-2025" ||
+		second.Currency != "USD" || second.BillingPeriod != subscriptions.BillingYearly {
+		t.Errorf("second subscription = %+v", second)
+	}
+}
+
+func TestRunCSVHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		err  error
+	}{
+		{name: "empty file", file: "", err: ErrMissingHeader},
+		{name: "missing user_id", file: "service_name,price,start_date\n", err: ErrMissingHeader},
+		{name: "service_id instead of name", file: "service_id,service_name,price,user_id,start_date\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Run(context.Background(), &memoryStore{}, strings.NewReader(tt.file), FormatCSV, false)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Run error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRunNDJSON(t *testing.T) {
+	file := `{"service_name":"Yandex Plus","price":39900,"user_id":"` + testUserID + `","start_date":"07-2025"}` + "\n" +
+		"\n" +
+		`{"service_name":"Netflix","price":` + "\n" +
+		`{"service_name":"Okko","price":-1,"user_id":"` + testUserID + `","start_date":"07-2025"}` + "\n"
+
+	store := &memoryStore{}
+	report, err := Run(context.Background(), store, strings.NewReader(file), FormatNDJSON, false)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.Total != 3 || report.Valid != 1 || report.Imported != 1 || len(store.subs) != 1 {
+		t.Errorf("report = %+v, stored %d", report, len(store.subs))
+	}
+	// номера строк считаются с учетом пустых строк
+	if len(report.Errors) != 2 || report.Errors[0].Row != 3 || report.Errors[1].Row != 4 || report.Errors[1].Field != "price" {
+		t.Errorf("errors = %+v", report.Errors)
+	}
+}
+
+func TestRunDryRun(t *testing.T) {
+	file := "service_name,price,user_id,start_date\n" +
+		"Yandex Plus,399," + testUserID + ",07-2025\n" +
+		"Netflix,-1," + testUserID + ",07-2025\n"
+
+	store := &memoryStore{}
+	report, err := Run(context.Background(), store, strings.NewReader(file), FormatCSV, true)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !report.DryRun || report.Total != 2 || report.Valid != 1 || report.Failed != 1 || report.Imported != 0 {
+		t.Errorf("report = %+v", report)
+	}
+	if len(store.subs) != 0 {
+		t.Errorf("dry run stored %d subscriptions", len(store.subs))
+	}
+}
+
+func TestRunWrongFormat(t *testing.T) {
+	if _, err := Run(context.Background(), &memoryStore{}, strings.NewReader(""), "xml", false); !errors.Is(err, ErrWrongFormat) {
+		t.Errorf("Run error = %v, want %v", err, ErrWrongFormat)
+	}
+}
+
+func TestReportErrorsTruncated(t *testing.T) {
+	report := &Report{}
+	for i := 0; i < MaxReportErrors+5; i++ {
+		report.addError(i+2, subscriptions.ErrWrongPrice)
+	}
+	if report.Failed != MaxReportErrors+5 || len(report.Errors) != MaxReportErrors || !report.ErrorsTruncated {
+		t.Errorf("failed = %d, errors = %d, truncated = %v", report.Failed, len(report.Errors), report.ErrorsTruncated)
+	}
+}
+
+func TestWriteErrorsCSV(t *testing.T) {
+	report := &Report{Errors: []RowError{
+		{Row: 3, Field: "price", Message: "wrong amount"},
+		{Row: 6, Message: "record on line 6: wrong number of fields"},
+	}}
+	var buf bytes.Buffer
+	if err := WriteErrorsCSV(&buf, report); err != nil {
+		t.Fatalf("WriteErrorsCSV: %v", err)
+	}
+	want := "row,field,message\n3,price,wrong amount\n6,,record on line 6: wrong number of fields\n"
+	if buf.String() != want {
+		t.Errorf("WriteErrorsCSV = %q, want %q", buf.String(), want)
+	}
+}
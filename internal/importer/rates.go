@@ -0,0 +1,56 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/subscriptions_api/subscriptions"
+)
+
+// ratesHeader - колонки CSV файла с курсами валют
+var ratesHeader = []string{"currency", "month", "rate"}
+
+// ReadRates читает курсы валют из CSV с заголовком currency,month,rate.
+// В отличие от импорта подписок, ошибочная строка отменяет загрузку всего файла
+func ReadRates(r io.Reader) ([]subscriptions.Rate, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = len(ratesHeader)
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("[ReadRates] %w", ErrMissingHeader)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("[ReadRates|read header] %w", err)
+	}
+	for i, name := range ratesHeader {
+		if strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))) != name {
+			return nil, fmt.Errorf("[ReadRates|column %s] %w", name, ErrMissingHeader)
+		}
+	}
+
+	rates := []subscriptions.Rate{}
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return rates, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("[ReadRates|read] %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		rate := subscriptions.Rate{
+			Currency: strings.ToUpper(strings.TrimSpace(record[0])),
+			Month:    strings.TrimSpace(record[1]),
+			Rate:     strings.TrimSpace(record[2]),
+		}
+		if err := subscriptions.ValidateRate(&rate); err != nil {
+			return nil, fmt.Errorf("[ReadRates|line %d] %w", line, err)
+		}
+		rates = append(rates, rate)
+	}
+}
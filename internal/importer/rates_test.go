@@ -0,0 +1,48 @@
+package importer
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/subscriptions_api/subscriptions"
+)
+
+func TestReadRates(t *testing.T) {
+	file := "\ufeffCurrency, Month, Rate\n" +
+		"usd, 01-2025, 92.5\n" +
+		"EUR,02-2025,99.1\n"
+	rates, err := ReadRates(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("ReadRates: %v", err)
+	}
+	want := []subscriptions.Rate{
+		{Currency: "USD", Month: "01-2025", Rate: "92.5"},
+		{Currency: "EUR", Month: "02-2025", Rate: "99.1"},
+	}
+	if !reflect.DeepEqual(rates, want) {
+		t.Errorf("ReadRates = %+v, want %+v", rates, want)
+	}
+}
+
+func TestReadRatesInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		err  error
+	}{
+		{name: "empty file", file: "", err: ErrMissingHeader},
+		{name: "wrong header", file: "month,currency,rate\n", err: ErrMissingHeader},
+		// ошибочная строка отменяет загрузку всего файла
+		{name: "wrong rate", file: "currency,month,rate\nUSD,01-2025,92.5\nEUR,02-2025,-1\n", err: subscriptions.ErrWrongRate},
+		{name: "base currency", file: "currency,month,rate\nRUB,01-2025,1\n", err: subscriptions.ErrWrongCurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadRates(strings.NewReader(tt.file)); !errors.Is(err, tt.err) {
+				t.Errorf("ReadRates error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
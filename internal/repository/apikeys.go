@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/subscriptions_api/internal/auth"
+)
+
+var (
+	ErrAPIKeyDoesNotExist = errors.New("api key with this id does not exist")
+)
+
+// GetAPIKeyByHash возвращает действующий (не отозванный) API ключ по хэшу
+func (r *PostgresRepository) GetAPIKeyByHash(ctx context.Context, hash string) (*auth.APIKey, error) {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	key := &auth.APIKey{}
+	var userID *uuid.UUID
+	err := r.pool.QueryRow(ctx, `SELECT api_key_id, name, user_id, role FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL`, hash).Scan(&key.ID, &key.Name, &userID, &key.Role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("[GetAPIKeyByHash] %w", auth.ErrInvalidAPIKey)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("[GetAPIKeyByHash|exec get key] %w", err)
+	}
+	if userID != nil {
+		key.UserID = *userID
+	}
+	return key, nil
+}
+
+// CreateAPIKey сохраняет хэш нового API ключа и возвращает id ключа
+func (r *PostgresRepository) CreateAPIKey(ctx context.Context, name, hash, role string, userID uuid.UUID) (int, error) {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	var owner *uuid.UUID
+	if userID != uuid.Nil {
+		owner = &userID
+	}
+	var id int
+	err := r.pool.QueryRow(ctx, `INSERT INTO api_keys (name, key_hash, role, user_id)
+		VALUES($1, $2, $3, $4) RETURNING api_key_id`, name, hash, role, owner).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("[CreateAPIKey|exec insert key] %w", err)
+	}
+	return id, nil
+}
+
+// RevokeAPIKey отзывает API ключ; отозванный ключ перестает приниматься сразу
+func (r *PostgresRepository) RevokeAPIKey(ctx context.Context, id int) error {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	tag, err := r.pool.Exec(ctx, `UPDATE api_keys SET revoked_at = now()
+		WHERE api_key_id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("[RevokeAPIKey|exec revoke key] %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("[RevokeAPIKey] %w", ErrAPIKeyDoesNotExist)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrAsOfBeforeJournal - момент раньше начала журнала, состояние подписок на него неизвестно
+	ErrAsOfBeforeJournal = errors.New("as_of is before the start of the subscription journal")
+)
+
+// checkAsOf проверяет, что состояние на момент asOf можно восстановить по журналу; nil - текущее состояние
+func checkAsOf(ctx context.Context, q querier, asOf *time.Time) error {
+	if asOf == nil {
+		return nil
+	}
+	var before bool
+	if err := q.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM subscription_journal WHERE started_at > $1)`, *asOf).Scan(&before); err != nil {
+		return fmt.Errorf("[checkAsOf|exec get journal start] %w", err)
+	}
+	if before {
+		return fmt.Errorf("[checkAsOf] %w", ErrAsOfBeforeJournal)
+	}
+	return nil
+}
+
+// subscriptionsFrom возвращает источник строк для FROM: таблицу subscriptions или ее состояние на момент asOf
+// по последнему событию журнала; колонки, которых нет в старых событиях, получают значения по умолчанию
+func subscriptionsFrom(where *whereBuilder, asOf *time.Time) string {
+	if asOf == nil {
+		return "subscriptions"
+	}
+	return `(SELECT s.subscription_id,
+		COALESCE(s.service_id, (SELECT service_id FROM service_aliases WHERE alias_key = service_key(s.service_name)), 0) AS service_id,
+		s.service_name, s.price, COALESCE(s.currency, 'RUB') AS currency,
+		COALESCE(s.billing_period, 'monthly') AS billing_period, s.billing_months, s.user_id, s.start_date, s.end_date,
+		s.start_day, s.end_day, s.version, s.deleted_at
+		FROM (
+			SELECT DISTINCT ON (subscription_id) subscription_event_state(after, price_unit) AS after
+			FROM subscription_events
+			WHERE operation <> 'price' AND occurred_at <= ` + where.placeholder(*asOf) + `
+			ORDER BY subscription_id, occurred_at DESC, event_id DESC
+		) e, jsonb_populate_record(NULL::subscriptions, e.after) s
+		WHERE e.after IS NOT NULL) AS subscriptions`
+}
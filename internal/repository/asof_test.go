@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+func TestSubscriptionsFrom(t *testing.T) {
+	where := &whereBuilder{}
+	where.add("subscription_id = %s", 7)
+	if got := subscriptionsFrom(where, nil); got != "subscriptions" {
+		t.Errorf("subscriptionsFrom without as_of = %q, want subscriptions", got)
+	}
+
+	// момент as_of передается следующим аргументом запроса
+	asOf := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	from := subscriptionsFrom(where, &asOf)
+	if !strings.Contains(from, "occurred_at <= $2") || !strings.HasSuffix(from, ") AS subscriptions") {
+		t.Errorf("subscriptionsFrom with as_of = %q", from)
+	}
+	if !reflect.DeepEqual(where.args, []interface{}{7, asOf}) {
+		t.Errorf("args = %v, want [7 %v]", where.args, asOf)
+	}
+}
+
+func TestSubscriptionAsOf(t *testing.T) {
+	pool, m := testMigrations(t)
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatalf("migrate up: %v", err)
+	}
+	repo := NewPostgresRepository(pool, Timeouts{})
+	ctx := context.Background()
+
+	sub := &subscriptions.Subscription{ServiceName: "Yandex Plus", Price: 39900, UserID: uuid.Must(uuid.NewV4()), StartDate: "01-2025"}
+	if err := subscriptions.Validate(sub); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CreateSubscription(ctx, sub); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	created := currentTime(t, repo)
+	updated := *sub
+	updated.Price = 49900
+	if err := repo.UpdateSubscriptionById(ctx, sub.ID, AnyVersion, &updated); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := repo.DeleteSubscriptionById(ctx, sub.ID, AnyVersion); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	got, err := repo.GetSubscriptionAsOf(ctx, sub.ID, created)
+	if err != nil {
+		t.Fatalf("GetSubscriptionAsOf: %v", err)
+	}
+	if got.Price != sub.Price || got.Version != sub.Version {
+		t.Errorf("subscription as of creation = price %d version %d, want price %d version %d", got.Price, got.Version, sub.Price, sub.Version)
+	}
+	// удаленная подписка в текущем состоянии отсутствует
+	if _, err := repo.GetSubscriptionAsOf(ctx, sub.ID, currentTime(t, repo)); !errors.Is(err, ErrSubscriptionDoesNotExist) {
+		t.Errorf("deleted subscription: error = %v, want %v", err, ErrSubscriptionDoesNotExist)
+	}
+
+	// раньше начала журнала состояние неизвестно, и выгрузка отклоняется до начала чтения
+	beforeJournal := created.Add(-24 * time.Hour)
+	if _, err := repo.ExportSubscriptions(ctx, &subscriptions.ListFilter{AsOf: &beforeJournal}); !errors.Is(err, ErrAsOfBeforeJournal) {
+		t.Errorf("export before journal: error = %v, want %v", err, ErrAsOfBeforeJournal)
+	}
+}
+
+// currentTime возвращает время БД: события журнала записываются по ее часам
+func currentTime(t *testing.T, repo *PostgresRepository) time.Time {
+	t.Helper()
+	var now time.Time
+	if err := repo.pool.QueryRow(context.Background(), "SELECT clock_timestamp()").Scan(&now); err != nil {
+		t.Fatal(err)
+	}
+	return now
+}
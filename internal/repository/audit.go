@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/subscriptions_api/internal/auth"
+)
+
+// anonymousActor - автор изменений, сделанных без аутентифицированного клиента
+const anonymousActor = "anonymous"
+
+// actorOf возвращает автора изменения для журнала: способ аутентификации и имя клиента
+func actorOf(ctx context.Context) string {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return anonymousActor
+	}
+	return principal.Method + ":" + principal.Subject
+}
+
+// beginAudited начинает транзакцию, изменения в которой попадут в журнал subscription_events
+// от имени клиента из контекста. Журнал пишет триггер, автор передается ему через настройку транзакции
+func (r *PostgresRepository) beginAudited(ctx context.Context) (pgx.Tx, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[beginAudited|begin tx] %w", err)
+	}
+	if _, err := tx.Exec(ctx, "SELECT set_config('subscriptions.actor', $1, true)", actorOf(ctx)); err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("[beginAudited|set actor] %w", err)
+	}
+	return tx, nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/subscriptions_api/internal/auth"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+func TestActorOf(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		want      string
+	}{
+		{name: "anonymous", want: anonymousActor},
+		{name: "jwt", principal: &auth.Principal{Subject: ownerID.String(), Method: auth.MethodJWT}, want: "jwt:" + ownerID.String()},
+		{name: "api key", principal: &auth.Principal{Subject: "billing", Method: auth.MethodAPIKey}, want: "api_key:billing"},
+		{name: "system", principal: auth.System("retention"), want: "system:retention"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, tt.principal)
+			}
+			if got := actorOf(ctx); got != tt.want {
+				t.Errorf("actorOf = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscriptionHistory(t *testing.T) {
+	pool, m := testMigrations(t)
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatalf("migrate up: %v", err)
+	}
+	repo := NewPostgresRepository(pool, Timeouts{})
+
+	userID := uuid.Must(uuid.NewV4())
+	user := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "billing", UserID: userID, Role: auth.RoleUser, Method: auth.MethodAPIKey})
+	system := auth.WithPrincipal(context.Background(), auth.System("retention"))
+
+	sub := &subscriptions.Subscription{ServiceName: "Yandex Plus", Price: 39900, UserID: userID, StartDate: "01-2025"}
+	if err := subscriptions.Validate(sub); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CreateSubscription(user, sub); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	sub.Price = 49900
+	if err := repo.UpdateSubscriptionById(user, sub.ID, AnyVersion, sub); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := repo.DeleteSubscriptionById(user, sub.ID, AnyVersion); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := repo.RestoreSubscriptionById(user, sub.ID, AnyVersion); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if err := repo.DeleteSubscriptionById(user, sub.ID, AnyVersion); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := repo.PurgeDeletedSubscriptions(system, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+
+	// после очистки история остается доступна владельцу, но не другому пользователю
+	other := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "other", UserID: uuid.Must(uuid.NewV4()), Role: auth.RoleUser, Method: auth.MethodAPIKey})
+	if _, err := repo.GetSubscriptionHistory(other, sub.ID); !errors.Is(err, ErrSubscriptionDoesNotExist) {
+		t.Errorf("other user history after purge: error = %v, want %v", err, ErrSubscriptionDoesNotExist)
+	}
+	owned, err := repo.GetSubscriptionHistory(user, sub.ID)
+	if err != nil {
+		t.Fatalf("owner history after purge: %v", err)
+	}
+	events, err := repo.GetSubscriptionHistory(system, sub.ID)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(owned) != len(events) {
+		t.Errorf("owner history = %d events, want %d", len(owned), len(events))
+	}
+
+	want := []struct{ operation, actor string }{
+		{subscriptions.EventCreate, "api_key:billing"},
+		{subscriptions.EventUpdate, "api_key:billing"},
+		{subscriptions.EventDelete, "api_key:billing"},
+		{subscriptions.EventRestore, "api_key:billing"},
+		{subscriptions.EventDelete, "api_key:billing"},
+		{subscriptions.EventPurge, "system:retention"},
+	}
+	got := make([]subscriptions.Event, 0, len(events))
+	for _, e := range events {
+		// изменения цены журналируются отдельно и здесь не проверяются
+		if e.Operation != subscriptions.EventPrice {
+			got = append(got, e)
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("history = %d events, want %d", len(got), len(want))
+	}
+	for i, e := range got {
+		if e.Operation != want[i].operation || e.Actor != want[i].actor || e.SubscriptionID != sub.ID {
+			t.Errorf("event %d = %s by %s, want %s by %s", i, e.Operation, e.Actor, want[i].operation, want[i].actor)
+		}
+	}
+	if got[0].Before != nil || got[0].After == nil || got[len(got)-1].After != nil {
+		t.Errorf("create and purge events: before = %s, after = %s", got[0].Before, got[len(got)-1].After)
+	}
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+var (
+	ErrBatchRolledBack = errors.New("batch rolled back because of another item")
+)
+
+// errNotApplied - операция изменения или удаления не затронула ни одной строки
+var errNotApplied = errors.New("no rows affected")
+
+// ApplyBatch выполняет операции пакета одной транзакцией, отправляя их в БД пачками
+// без ожидания ответа на каждую операцию. Для каждой операции возвращается ошибка (nil - успешно),
+// id и версии созданных, измененных и удаленных подписок проставляются в items.
+// В режиме atomic любая ошибка откатывает весь пакет, а остальным операциям проставляется ErrBatchRolledBack.
+// Иначе каждая операция выполняется внутри своей точки сохранения и упавшая операция откатывается отдельно
+func (r *PostgresRepository) ApplyBatch(ctx context.Context, items []*subscriptions.BatchItem, atomic bool) ([]error, error) {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Bulk)
+	defer cancel()
+
+	scope, err := scopeFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[ApplyBatch] %w", err)
+	}
+
+	// операции, создающие или изменяющие чужие подписки, в БД не отправляются
+	results := make([]error, len(items))
+	allowed := make([]*subscriptions.BatchItem, 0, len(items))
+	allowedIdx := make([]int, 0, len(items))
+	for i, item := range items {
+		if item.Op != subscriptions.BatchOpDelete {
+			if err := scope.check(item.Subscription.UserID); err != nil {
+				results[i] = fmt.Errorf("[ApplyBatch|item %d] %w", i, err)
+				if atomic {
+					return rollBackBatch(results, i), nil
+				}
+				continue
+			}
+		}
+		allowed = append(allowed, item)
+		allowedIdx = append(allowedIdx, i)
+	}
+	if len(allowed) == 0 {
+		return results, nil
+	}
+
+	allowedResults, err := r.applyBatch(ctx, allowed, atomic, scope)
+	if err != nil {
+		return nil, fmt.Errorf("[ApplyBatch] %w", err)
+	}
+	for k, i := range allowedIdx {
+		results[i] = allowedResults[k]
+	}
+	return results, nil
+}
+
+// applyBatch выполняет операции в транзакции; update и delete затрагивают только подписки из scope
+func (r *PostgresRepository) applyBatch(ctx context.Context, items []*subscriptions.BatchItem, atomic bool, scope ownerScope) ([]error, error) {
+	tx, err := r.beginAudited(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[applyBatch] %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	results := make([]error, len(items))
+	for pending := 0; pending < len(items); {
+		failed, err := sendBatchChunk(ctx, tx, items[pending:], results[pending:], !atomic, scope)
+		if err != nil {
+			return nil, fmt.Errorf("[applyBatch] %w", err)
+		}
+		if failed < 0 {
+			break
+		}
+
+		failed += pending
+		if atomic {
+			return rollBackBatch(results, failed), nil
+		}
+		// откатываем только упавшую операцию и продолжаем со следующей
+		if _, err := tx.Exec(ctx, "ROLLBACK TO SAVEPOINT batch_item; RELEASE SAVEPOINT batch_item"); err != nil {
+			return nil, fmt.Errorf("[applyBatch|rollback to savepoint] %w", err)
+		}
+		pending = failed + 1
+	}
+
+	// операция не затронула строк: записи нет или не совпала версия
+	for i, itemErr := range results {
+		if !errors.Is(itemErr, errNotApplied) {
+			continue
+		}
+		results[i] = fmt.Errorf("[applyBatch|item %d] %w", i, ErrVersionMismatch)
+		if err := checkExistsSubscription(ctx, tx, items[i].ID, scope); err != nil {
+			if !errors.Is(err, ErrSubscriptionDoesNotExist) {
+				return nil, fmt.Errorf("[applyBatch] %w", err)
+			}
+			results[i] = fmt.Errorf("[applyBatch|item %d] %w", i, err)
+		}
+		if atomic {
+			return rollBackBatch(results, i), nil
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("[applyBatch|commit] %w", err)
+	}
+	return results, nil
+}
+
+// rollBackBatch помечает все операции, кроме упавшей, как откаченные
+func rollBackBatch(results []error, failed int) []error {
+	for i := range results {
+		if i != failed {
+			results[i] = ErrBatchRolledBack
+		}
+	}
+	return results
+}
+
+// sendBatchChunk отправляет операции одной пачкой и читает результаты по порядку.
+// Возвращает индекс операции, на которой БД прервала транзакцию, или -1, если пачка выполнена целиком
+func sendBatchChunk(ctx context.Context, tx pgx.Tx, items []*subscriptions.BatchItem, results []error, savepoints bool, scope ownerScope) (int, error) {
+	batch := &pgx.Batch{}
+	for _, item := range items {
+		if savepoints {
+			batch.Queue("SAVEPOINT batch_item")
+		}
+		if err := queueBatchItem(batch, item, scope); err != nil {
+			return -1, err
+		}
+		if savepoints {
+			batch.Queue("RELEASE SAVEPOINT batch_item")
+		}
+	}
+
+	br := tx.SendBatch(ctx, batch)
+	defer br.Close()
+
+	for i, item := range items {
+		if savepoints {
+			if _, err := br.Exec(); err != nil {
+				return -1, fmt.Errorf("[sendBatchChunk|savepoint] %w", err)
+			}
+		}
+
+		err := scanBatchItem(br.QueryRow(), item)
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			results[i] = errNotApplied
+		case errors.As(err, &pgErr):
+			// ошибка выполнения запроса прерывает транзакцию до конца пачки
+			results[i] = fmt.Errorf("[sendBatchChunk|item %s] %w", item.Op, err)
+			return i, nil
+		case err != nil:
+			return -1, fmt.Errorf("[sendBatchChunk|exec item] %w", err)
+		}
+
+		if savepoints {
+			if _, err := br.Exec(); err != nil {
+				return -1, fmt.Errorf("[sendBatchChunk|release savepoint] %w", err)
+			}
+		}
+	}
+	return -1, nil
+}
+
+// queueBatchItem добавляет в пачку запрос, выполняющий операцию
+func queueBatchItem(batch *pgx.Batch, item *subscriptions.BatchItem, scope ownerScope) error {
+	if item.Op == subscriptions.BatchOpDelete {
+		batch.Queue(`UPDATE subscriptions
+		SET deleted_at = now(), version = version + 1
+		WHERE subscription_id = $1 AND ($2 = 0 OR version = $2) AND ($3::uuid IS NULL OR user_id = $3) AND deleted_at IS NULL
+		RETURNING subscription_id, version`, item.ID, item.Version, scope.arg())
+		return nil
+	}
+
+	sub := item.Subscription
+	start, end, err := sub.DateRange()
+	if err != nil {
+		return fmt.Errorf("[queueBatchItem|dates] %w", err)
+	}
+	startDay, endDay := sub.Days()
+
+	if item.Op == subscriptions.BatchOpCreate {
+		batch.Queue(`INSERT INTO subscriptions (service_id , service_name , price , currency , billing_period , billing_months , user_id , start_date , end_date , start_day , end_day)
+		VALUES(NULLIF($1, 0) , $2 , $3 , $4 , $5 , NULLIF($6, 0) , $7 , $8 , $9 , NULLIF($10, 0) , NULLIF($11, 0))
+		RETURNING subscription_id, version, service_id, service_name`,
+			sub.ServiceID, sub.ServiceName, sub.Price, sub.Currency, sub.BillingPeriod, sub.BillingMonths, sub.UserID, start, end, startDay, endDay)
+		return nil
+	}
+
+	batch.Queue(`UPDATE subscriptions
+		SET service_id = NULLIF($1, 0), service_name = $2, price = $3, currency = $4, billing_period = $5, billing_months = NULLIF($6, 0),
+			user_id = $7, start_date = $8 , end_date = $9, start_day = NULLIF($10, 0), end_day = NULLIF($11, 0), version = version + 1
+		WHERE subscription_id = $12 AND ($13 = 0 OR version = $13) AND ($14::uuid IS NULL OR user_id = $14) AND deleted_at IS NULL
+		RETURNING subscription_id, version, service_id, service_name`,
+		sub.ServiceID, sub.ServiceName, sub.Price, sub.Currency, sub.BillingPeriod, sub.BillingMonths,
+		sub.UserID, start, end, startDay, endDay, item.ID, item.Version, scope.arg())
+	return nil
+}
+
+// scanBatchItem читает результат операции и проставляет id и новую версию записи;
+// удаление тоже меняет версию, и для восстановления или If-Match нужна именно она
+func scanBatchItem(row pgx.Row, item *subscriptions.BatchItem) error {
+	if item.Op == subscriptions.BatchOpDelete {
+		return row.Scan(&item.ID, &item.Version)
+	}
+
+	if err := row.Scan(&item.Subscription.ID, &item.Subscription.Version, &item.Subscription.ServiceID, &item.Subscription.ServiceName); err != nil {
+		return err
+	}
+	item.ID = item.Subscription.ID
+	item.Version = item.Subscription.Version
+	return nil
+}
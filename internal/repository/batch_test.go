@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRollBackBatch(t *testing.T) {
+	failed := errors.New("failed")
+	results := rollBackBatch([]error{nil, failed, nil, nil}, 1)
+	for i, err := range results {
+		want := ErrBatchRolledBack
+		if i == 1 {
+			want = failed
+		}
+		if !errors.Is(err, want) {
+			t.Errorf("results[%d] = %v, want %v", i, err, want)
+		}
+	}
+}
@@ -3,28 +3,56 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/subscriptions_api/internal/logger"
 )
 
-var PostgresDB *pgx.Conn
+// PoolConfig задает размер пула соединений и время жизни соединений
+type PoolConfig struct {
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+}
 
-func NewPostgresDB(ctx context.Context, dsn string) (*pgx.Conn, error) {
+func NewPostgresDB(ctx context.Context, dsn string, poolCfg PoolConfig) (*pgxpool.Pool, error) {
 
 	logger.L.Debug("Start connecting to Postgres DB")
-	conn, err := pgx.Connect(ctx, dsn)
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("[NewPostgresDB|parse config] , %w", err)
+	}
+
+	// нулевые значения оставляют настройки pgxpool по умолчанию
+	if poolCfg.MaxConns > 0 {
+		cfg.MaxConns = poolCfg.MaxConns
+	}
+	cfg.MinConns = poolCfg.MinConns
+	if poolCfg.MaxConnLifetime > 0 {
+		cfg.MaxConnLifetime = poolCfg.MaxConnLifetime
+	}
+	if poolCfg.MaxConnIdleTime > 0 {
+		cfg.MaxConnIdleTime = poolCfg.MaxConnIdleTime
+	}
+	if poolCfg.HealthCheckPeriod > 0 {
+		cfg.HealthCheckPeriod = poolCfg.HealthCheckPeriod
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("[NewPostgresDB|connect DB] , %w", err)
 	}
 
 	// Проверка подключения
-	err = conn.Ping(ctx)
+	err = pool.Ping(ctx)
 	if err != nil {
+		pool.Close()
 		return nil, fmt.Errorf("[NewPostgresDB|ping] ,%w", err)
 	}
 
-	PostgresDB = conn
-	logger.L.Info("Successful connected to DB")
-	return PostgresDB, nil
+	logger.L.Info("Successful connected to DB", "max_conns", cfg.MaxConns)
+	return pool, nil
 }
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/subscriptions_api/internal/logger"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+func TestNewPostgresDB(t *testing.T) {
+	if logger.L == nil {
+		logger.L = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	if _, err := NewPostgresDB(context.Background(), "postgres://%zz", PoolConfig{}); err == nil {
+		t.Error("NewPostgresDB with invalid dsn succeeded")
+	}
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	pool, err := NewPostgresDB(context.Background(), dsn, PoolConfig{MaxConns: 3, MaxConnIdleTime: time.Minute})
+	if err != nil {
+		t.Fatalf("NewPostgresDB: %v", err)
+	}
+	defer pool.Close()
+	// нулевые значения оставляют настройки pgxpool по умолчанию
+	if cfg := pool.Config(); cfg.MaxConns != 3 || cfg.MaxConnIdleTime != time.Minute || cfg.MaxConnLifetime != time.Hour {
+		t.Errorf("pool config = max conns %d, idle %v, lifetime %v", cfg.MaxConns, cfg.MaxConnIdleTime, cfg.MaxConnLifetime)
+	}
+}
+
+func TestPostgresRepositoryCRUD(t *testing.T) {
+	pool, m := testMigrations(t)
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatalf("migrate up: %v", err)
+	}
+	repo := NewPostgresRepository(pool, Timeouts{Read: time.Second, Write: time.Second})
+	ctx := context.Background()
+
+	sub := &subscriptions.Subscription{ServiceName: "Yandex Plus", Price: 39900, UserID: uuid.Must(uuid.NewV4()), StartDate: "07-2025"}
+	if err := subscriptions.Validate(sub); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CreateSubscription(ctx, sub); err != nil {
+		t.Fatalf("CreateSubscription: %v", err)
+	}
+	if sub.ID == 0 {
+		t.Fatal("CreateSubscription did not set subscription_id")
+	}
+
+	sub.Price = 49900
+	if err := repo.UpdateSubscriptionById(ctx, sub.ID, AnyVersion, sub); err != nil {
+		t.Fatalf("UpdateSubscriptionById: %v", err)
+	}
+	got, err := repo.GetSubscriptionById(ctx, sub.ID)
+	if err != nil {
+		t.Fatalf("GetSubscriptionById: %v", err)
+	}
+	if got.ID != sub.ID || got.Price != 49900 || got.UserID != sub.UserID || got.StartDate != "07-2025" {
+		t.Errorf("GetSubscriptionById = %+v", got)
+	}
+
+	if err := repo.DeleteSubscriptionById(ctx, sub.ID, AnyVersion); err != nil {
+		t.Fatalf("DeleteSubscriptionById: %v", err)
+	}
+	if _, err := repo.GetSubscriptionById(ctx, sub.ID); !errors.Is(err, ErrSubscriptionDoesNotExist) {
+		t.Errorf("GetSubscriptionById after delete: error = %v, want %v", err, ErrSubscriptionDoesNotExist)
+	}
+	if err := repo.UpdateSubscriptionById(ctx, sub.ID+1000, AnyVersion, sub); !errors.Is(err, ErrSubscriptionDoesNotExist) {
+		t.Errorf("UpdateSubscriptionById of missing subscription: error = %v, want %v", err, ErrSubscriptionDoesNotExist)
+	}
+}
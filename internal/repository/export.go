@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/subscriptions_api/subscriptions"
+)
+
+// SubscriptionExport - выгрузка подписок, для которой уже проверены доступ клиента и as_of и построены условия выборки
+type SubscriptionExport struct {
+	repo  *PostgresRepository
+	query string
+	args  []any
+}
+
+// ExportSubscriptions проверяет доступ клиента к подпискам фильтра и as_of и готовит выгрузку.
+// Ошибки доступа и as_of раньше начала журнала возвращаются здесь, чтобы обработчик ответил ими до начала потоковой выгрузки
+func (r *PostgresRepository) ExportSubscriptions(ctx context.Context, filter *subscriptions.ListFilter) (*SubscriptionExport, error) {
+	where, err := scopedListWhere(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("[ExportSubscriptions] %w", err)
+	}
+	if err := r.checkExportAsOf(ctx, filter); err != nil {
+		return nil, fmt.Errorf("[ExportSubscriptions] %w", err)
+	}
+	query := `SELECT ` + subscriptionColumns + ` FROM ` + subscriptionsFrom(where, filter.AsOf) + where.String() + listOrderBy(filter)
+	return &SubscriptionExport{repo: r, query: query, args: where.args}, nil
+}
+
+// checkExportAsOf проверяет as_of выгрузки с таймаутом чтения: сама выгрузка ограничена таймаутом Bulk позже
+func (r *PostgresRepository) checkExportAsOf(ctx context.Context, filter *subscriptions.ListFilter) error {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+	return checkAsOf(ctx, r.pool, filter.AsOf)
+}
+
+// Each вызывает fn для каждой подписки выгрузки в порядке сортировки фильтра.
+// Limit и Cursor не учитываются: строки читаются из БД по мере обработки, без загрузки всей выборки в память.
+// Ошибка fn прерывает выгрузку и возвращается как есть; строки закрываются сразу, без дочитывания
+func (e *SubscriptionExport) Each(ctx context.Context, fn func(sub *subscriptions.Subscription) error) error {
+	ctx, cancel := e.repo.withTimeout(ctx, e.repo.timeouts.Bulk)
+	defer cancel()
+
+	rows, err := e.repo.pool.Query(ctx, e.query, e.args...)
+	if err != nil {
+		return fmt.Errorf("[ExportSubscriptions|exec get subs] %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return fmt.Errorf("[ExportSubscriptions|scan sub] %w", err)
+		}
+		if err := fn(sub); err != nil {
+			cancel()
+			rows.Close()
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("[ExportSubscriptions|read rows] %w", err)
+	}
+	return nil
+}
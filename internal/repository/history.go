@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/subscriptions_api/subscriptions"
+)
+
+// GetSubscriptionHistory возвращает журнал изменений подписки в порядке изменений.
+// История удаленных и очищенных подписок остается доступной; клиенту с ограничением по user_id
+// доступна история подписок, последнее состояние которых в журнале принадлежит его пользователю
+func (r *PostgresRepository) GetSubscriptionHistory(ctx context.Context, id int) ([]subscriptions.Event, error) {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	scope, err := scopeFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[GetSubscriptionHistory] %w", err)
+	}
+	if scope.restricted {
+		// владелец берется из журнала: после очистки строки подписки уже нет
+		var owned bool
+		err := r.pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM (
+				SELECT COALESCE(after, before) ->> 'user_id' AS user_id
+				FROM subscription_events
+				WHERE subscription_id = $1 AND operation <> 'price'
+				ORDER BY occurred_at DESC, event_id DESC
+				LIMIT 1
+			) e WHERE e.user_id = $2)`, id, scope.userID.String()).Scan(&owned)
+		if err != nil {
+			return nil, fmt.Errorf("[GetSubscriptionHistory|exec check owner] %w", err)
+		}
+		if !owned {
+			return nil, fmt.Errorf("[GetSubscriptionHistory] %w", ErrSubscriptionDoesNotExist)
+		}
+	}
+
+	rows, err := r.pool.Query(ctx, `SELECT event_id, subscription_id, operation, actor, occurred_at,
+		subscription_event_state(before, price_unit), subscription_event_state(after, price_unit)
+		FROM subscription_events
+		WHERE subscription_id = $1
+		ORDER BY occurred_at, event_id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("[GetSubscriptionHistory|exec get events] %w", err)
+	}
+	defer rows.Close()
+
+	events := []subscriptions.Event{}
+	for rows.Next() {
+		var e subscriptions.Event
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.SubscriptionID, &e.Operation, &e.Actor, &e.OccurredAt, &before, &after); err != nil {
+			return nil, fmt.Errorf("[GetSubscriptionHistory|scan event] %w", err)
+		}
+		e.Before, e.After = before, after
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("[GetSubscriptionHistory|read rows] %w", err)
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("[GetSubscriptionHistory] %w", ErrSubscriptionDoesNotExist)
+	}
+	return events, nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+// ImportSubscriptions вставляет подписки одной командой COPY, читая их из next по мере отправки.
+// next возвращает nil, когда подписки закончились; команда COPY выполняется атомарно
+func (r *PostgresRepository) ImportSubscriptions(ctx context.Context, next func() (*subscriptions.Subscription, error)) (int64, error) {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Bulk)
+	defer cancel()
+
+	scope, err := scopeFromContext(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("[ImportSubscriptions] %w", err)
+	}
+
+	source := pgx.CopyFromFunc(func() ([]any, error) {
+		sub, err := next()
+		if err != nil || sub == nil {
+			return nil, err
+		}
+		// подписка чужого пользователя отменяет весь импорт
+		if err := scope.check(sub.UserID); err != nil {
+			return nil, fmt.Errorf("[ImportSubscriptions] %w", err)
+		}
+		start, end, err := sub.DateRange()
+		if err != nil {
+			return nil, fmt.Errorf("[ImportSubscriptions|dates] %w", err)
+		}
+		startDay, endDay := sub.Days()
+		return []any{nullIfZero(sub.ServiceID), sub.ServiceName, sub.Price, sub.Currency,
+			sub.BillingPeriod, nullIfZero(sub.BillingMonths), sub.UserID, start, end, nullIfZero(startDay), nullIfZero(endDay)}, nil
+	})
+
+	tx, err := r.beginAudited(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("[ImportSubscriptions] %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	count, err := tx.CopyFrom(ctx, pgx.Identifier{"subscriptions"},
+		[]string{"service_id", "service_name", "price", "currency", "billing_period", "billing_months", "user_id", "start_date", "end_date", "start_day", "end_day"}, source)
+	if err != nil {
+		return 0, fmt.Errorf("[ImportSubscriptions|copy] %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("[ImportSubscriptions|commit] %w", err)
+	}
+	return count, nil
+}
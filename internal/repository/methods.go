@@ -4,134 +4,442 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/subscriptions_api/internal/logger"
 	"github.com/subscriptions_api/subscriptions"
 )
 
 var (
 	ErrSubscriptionDoesNotExist = errors.New("subscription with this id does not exist")
+	ErrVersionMismatch          = errors.New("subscription version does not match")
+	ErrSubscriptionNotDeleted   = errors.New("subscription is not deleted")
 )
 
-func CreateSubscription(ctx context.Context, sub *subscriptions.Subscription) error {
+// AnyVersion отключает проверку версии записи при изменении и удалении
+const AnyVersion = 0
+
+// subscriptionColumns - порядок колонок, ожидаемый scanSubscription
+const subscriptionColumns = "subscription_id, service_id, service_name, price, currency, billing_period, billing_months, user_id, start_date, end_date, start_day, end_day, version"
+
+// scanSubscription читает запись о подписке, выбранную в порядке subscriptionColumns
+func scanSubscription(row pgx.Row) (*subscriptions.Subscription, error) {
+	var (
+		sub           subscriptions.Subscription
+		billingMonths *int
+		start         time.Time
+		end           *time.Time
+		startDay      *int
+		endDay        *int
+	)
+	if err := row.Scan(&sub.ID, &sub.ServiceID, &sub.ServiceName, &sub.Price, &sub.Currency, &sub.BillingPeriod, &billingMonths,
+		&sub.UserID, &start, &end, &startDay, &endDay, &sub.Version); err != nil {
+		return nil, err
+	}
+	if billingMonths != nil {
+		sub.BillingMonths = *billingMonths
+	}
+	sub.SetDateRange(start, end, zeroIfNull(startDay), zeroIfNull(endDay))
+	return &sub, nil
+}
+
+// CreateSubscription добавляет запись о подписке и проставляет в sub сгенерированный id и версию
+func (r *PostgresRepository) CreateSubscription(ctx context.Context, sub *subscriptions.Subscription) error {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	scope, err := scopeFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("[CreateSubscription] %w", err)
+	}
+	if err := scope.check(sub.UserID); err != nil {
+		return fmt.Errorf("[CreateSubscription] %w", err)
+	}
+
+	start, end, err := sub.DateRange()
+	if err != nil {
+		return fmt.Errorf("[CreateSubscription|dates] %w", err)
+	}
+	startDay, endDay := sub.Days()
+
+	tx, err := r.beginAudited(ctx)
+	if err != nil {
+		return fmt.Errorf("[CreateSubscription] %w", err)
+	}
+	defer tx.Rollback(ctx)
 
 	logger.L.Debug("starting createSubsciprion DB request")
-	_, err := PostgresDB.Exec(ctx, "INSERT INTO subscriptions (service_name , price , user_id , start_date , end_date) VALUES($1 , $2 , $3 , $4 , $5)", sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate)
+	// название сервиса заменяется каноническим триггером БД, поэтому читаем его обратно
+	err = tx.QueryRow(ctx, `INSERT INTO subscriptions (service_id , service_name , price , currency , billing_period , billing_months , user_id , start_date , end_date , start_day , end_day)
+	VALUES(NULLIF($1, 0) , $2 , $3 , $4 , $5 , NULLIF($6, 0) , $7 , $8 , $9 , NULLIF($10, 0) , NULLIF($11, 0))
+	RETURNING subscription_id, version, service_id, service_name`,
+		sub.ServiceID, sub.ServiceName, sub.Price, sub.Currency, sub.BillingPeriod, sub.BillingMonths, sub.UserID, start, end, startDay, endDay).
+		Scan(&sub.ID, &sub.Version, &sub.ServiceID, &sub.ServiceName)
 
 	if err != nil {
 		return fmt.Errorf("[CreateSubscription|exec insert subscription request]: %w", err)
 	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("[CreateSubscription|commit] %w", err)
+	}
 	return nil
 }
 
-func GetSubscriptionById(ctx context.Context, id int) (*subscriptions.Subscription, error) {
+func (r *PostgresRepository) GetSubscriptionById(ctx context.Context, id int) (*subscriptions.Subscription, error) {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	scope, err := scopeFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[GetSubscriptionById] %w", err)
+	}
+
 	// проверим существование записи о подписке с таким id
-	if err := checkExistsSubscription(ctx, id); err != nil {
+	if err := checkExistsSubscription(ctx, r.pool, id, scope); err != nil {
 		return nil, fmt.Errorf("[GetSubscriptionById] %w", err)
 	}
-	var sub subscriptions.Subscription
-	err := PostgresDB.QueryRow(ctx, `SELECT service_name , price , user_id , start_date , end_date
+	sub, err := scanSubscription(r.pool.QueryRow(ctx, `SELECT `+subscriptionColumns+`
 	FROM subscriptions 
-	WHERE subscription_id = $1`, id).Scan(&sub.ServiceName, &sub.Price, &sub.UserID, &sub.StartDate, &sub.EndDate)
+	WHERE subscription_id = $1 AND ($2::uuid IS NULL OR user_id = $2) AND deleted_at IS NULL`, id, scope.arg()))
 	if err != nil {
 		return nil, fmt.Errorf("[GetSubscriptionById|exec get sub] %w", err)
 	}
-	return &sub, nil
+	return sub, nil
 }
 
-func UpdateSubscriptionById(ctx context.Context, id int, sub *subscriptions.Subscription) error {
+// UpdateSubscriptionById перезаписывает подписку, если ее версия совпадает с expectedVersion
+// (AnyVersion - без проверки), и проставляет в sub id и новую версию
+func (r *PostgresRepository) UpdateSubscriptionById(ctx context.Context, id int, expectedVersion int, sub *subscriptions.Subscription) error {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	scope, err := scopeFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("[UpdateSubscriptionById] %w", err)
+	}
+
+	tx, err := r.beginAudited(ctx)
+	if err != nil {
+		return fmt.Errorf("[UpdateSubscriptionById] %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	// проверим существование записи о подписке с таким id
-	if err := checkExistsSubscription(ctx, id); err != nil {
+	if err := checkExistsSubscription(ctx, tx, id, scope); err != nil {
+		return fmt.Errorf("[UpdateSubscriptionById] %w", err)
+	}
+	// передать подписку другому пользователю нельзя
+	if err := scope.check(sub.UserID); err != nil {
 		return fmt.Errorf("[UpdateSubscriptionById] %w", err)
 	}
 
-	_, err := PostgresDB.Exec(ctx, `
+	start, end, err := sub.DateRange()
+	if err != nil {
+		return fmt.Errorf("[UpdateSubscriptionById|dates] %w", err)
+	}
+	startDay, endDay := sub.Days()
+
+	err = tx.QueryRow(ctx, `
 		UPDATE subscriptions
-		SET service_name = $1, price = $2, user_id = $3, start_date = $4 , end_date = $5
-		WHERE subscription_id = $6`,
-		sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, id)
+		SET service_id = NULLIF($1, 0), service_name = $2, price = $3, currency = $4, billing_period = $5, billing_months = NULLIF($6, 0),
+			user_id = $7, start_date = $8 , end_date = $9, start_day = NULLIF($10, 0), end_day = NULLIF($11, 0), version = version + 1
+		WHERE subscription_id = $12 AND ($13 = 0 OR version = $13) AND ($14::uuid IS NULL OR user_id = $14) AND deleted_at IS NULL
+		RETURNING version, service_id, service_name`,
+		sub.ServiceID, sub.ServiceName, sub.Price, sub.Currency, sub.BillingPeriod, sub.BillingMonths,
+		sub.UserID, start, end, startDay, endDay, id, expectedVersion, scope.arg()).
+		Scan(&sub.Version, &sub.ServiceID, &sub.ServiceName)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// запись существует, значит не совпала версия
+		return fmt.Errorf("[UpdateSubscriptionById] %w", ErrVersionMismatch)
+	}
 	if err != nil {
 		return fmt.Errorf("[UpdateSubscriptionById|exec update sub] %w", err)
 	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("[UpdateSubscriptionById|commit] %w", err)
+	}
+	sub.ID = id
 	return nil
 }
 
-func DeleteSubscriptionById(ctx context.Context, id int) error {
+// PatchSubscriptionById читает подписку под блокировкой строки, проверяет версию, применяет к ней patch
+// и сохраняет результат в той же транзакции
+func (r *PostgresRepository) PatchSubscriptionById(ctx context.Context, id int, expectedVersion int, patch func(sub *subscriptions.Subscription) (*subscriptions.Subscription, error)) (*subscriptions.Subscription, error) {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	scope, err := scopeFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[PatchSubscriptionById] %w", err)
+	}
+
+	tx, err := r.beginAudited(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[PatchSubscriptionById] %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	current, err := scanSubscription(tx.QueryRow(ctx, `SELECT `+subscriptionColumns+`
+	FROM subscriptions
+	WHERE subscription_id = $1 AND ($2::uuid IS NULL OR user_id = $2) AND deleted_at IS NULL
+	FOR UPDATE`, id, scope.arg()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("[PatchSubscriptionById] %w", ErrSubscriptionDoesNotExist)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("[PatchSubscriptionById|exec get sub] %w", err)
+	}
+	if expectedVersion != AnyVersion && current.Version != expectedVersion {
+		return nil, fmt.Errorf("[PatchSubscriptionById] %w", ErrVersionMismatch)
+	}
+
+	patched, err := patch(current)
+	if err != nil {
+		return nil, fmt.Errorf("[PatchSubscriptionById|patch] %w", err)
+	}
+	if err := scope.check(patched.UserID); err != nil {
+		return nil, fmt.Errorf("[PatchSubscriptionById] %w", err)
+	}
+
+	start, end, err := patched.DateRange()
+	if err != nil {
+		return nil, fmt.Errorf("[PatchSubscriptionById|dates] %w", err)
+	}
+	startDay, endDay := patched.Days()
+
+	err = tx.QueryRow(ctx, `
+		UPDATE subscriptions
+		SET service_id = NULLIF($1, 0), service_name = $2, price = $3, currency = $4, billing_period = $5, billing_months = NULLIF($6, 0),
+			user_id = $7, start_date = $8 , end_date = $9, start_day = NULLIF($10, 0), end_day = NULLIF($11, 0), version = version + 1
+		WHERE subscription_id = $12
+		RETURNING version, service_id, service_name`,
+		patched.ServiceID, patched.ServiceName, patched.Price, patched.Currency, patched.BillingPeriod, patched.BillingMonths,
+		patched.UserID, start, end, startDay, endDay, id).
+		Scan(&patched.Version, &patched.ServiceID, &patched.ServiceName)
+	if err != nil {
+		return nil, fmt.Errorf("[PatchSubscriptionById|exec update sub] %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("[PatchSubscriptionById|commit] %w", err)
+	}
+	return patched, nil
+}
+
+// DeleteSubscriptionById помечает подписку удаленной, если ее версия совпадает с expectedVersion (AnyVersion - без проверки).
+// Удаленная подписка исключается из выборок и подсчетов, пока ее не восстановят или не очистят по сроку хранения
+func (r *PostgresRepository) DeleteSubscriptionById(ctx context.Context, id int, expectedVersion int) error {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	scope, err := scopeFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("[DeleteSubscriptionById] %w", err)
+	}
+
+	tx, err := r.beginAudited(ctx)
+	if err != nil {
+		return fmt.Errorf("[DeleteSubscriptionById] %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	// проверим существование записи о подписке с таким id
-	if err := checkExistsSubscription(ctx, id); err != nil {
-		return fmt.Errorf("[UpdateSubscriptionById] %w", err)
+	if err := checkExistsSubscription(ctx, tx, id, scope); err != nil {
+		return fmt.Errorf("[DeleteSubscriptionById] %w", err)
 	}
 
-	_, err := PostgresDB.Exec(ctx, "DELETE FROM subscriptions WHERE subscription_id = $1", id)
+	tag, err := tx.Exec(ctx, `UPDATE subscriptions
+		SET deleted_at = now(), version = version + 1
+		WHERE subscription_id = $1 AND ($2 = 0 OR version = $2) AND ($3::uuid IS NULL OR user_id = $3) AND deleted_at IS NULL`,
+		id, expectedVersion, scope.arg())
 	if err != nil {
 		return fmt.Errorf("[DeleteSubscriptionById|exec delete sub] %w", err)
 	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("[DeleteSubscriptionById] %w", ErrVersionMismatch)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("[DeleteSubscriptionById|commit] %w", err)
+	}
 	return nil
 }
 
-func GetAllSubscriptions(ctx context.Context) ([]*subscriptions.Subscription, error) {
-	subs := []*subscriptions.Subscription{}
-	query := `SELECT service_name, price, user_id, start_date, end_date FROM subscriptions`
-	rows, err := PostgresDB.Query(ctx, query)
+// GetSubscriptionAsOf возвращает подписку в том состоянии, в котором она была в момент asOf
+func (r *PostgresRepository) GetSubscriptionAsOf(ctx context.Context, id int, asOf time.Time) (*subscriptions.Subscription, error) {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	scope, err := scopeFromContext(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("[GetAllSubscriptions|exec get subs] %w", err)
+		return nil, fmt.Errorf("[GetSubscriptionAsOf] %w", err)
+	}
+	if err := checkAsOf(ctx, r.pool, &asOf); err != nil {
+		return nil, fmt.Errorf("[GetSubscriptionAsOf] %w", err)
 	}
 
-	for rows.Next() {
-		var curSub subscriptions.Subscription
-		err := rows.Scan(&curSub.ServiceName, &curSub.Price, &curSub.UserID, &curSub.StartDate, &curSub.EndDate)
-		if err != nil {
-			return nil, fmt.Errorf("[GetAllSubscriptions|exec get sub] %w", err)
-		}
+	where := &whereBuilder{}
+	where.add("subscription_id = %s", id)
+	where.addRaw("deleted_at IS NULL")
+	if scope.restricted {
+		where.add("user_id = %s", scope.userID)
+	}
 
-		subs = append(subs, &curSub)
+	// запрос собираем до передачи аргументов: subscriptionsFrom добавляет в where аргумент asOf
+	query := `SELECT ` + subscriptionColumns + ` FROM ` + subscriptionsFrom(where, &asOf) + where.String()
+	sub, err := scanSubscription(r.pool.QueryRow(ctx, query, where.args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("[GetSubscriptionAsOf] %w", ErrSubscriptionDoesNotExist)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("[GetSubscriptionAsOf|exec get sub] %w", err)
 	}
-	return subs, nil
+	return sub, nil
 }
 
-func GetTotalPriceInPeriod(ctx context.Context, validator *subscriptions.Subscription) (int, error) {
-	// приведем даты к формату DD-MM-YYYY для фильтрации по периоду
-	parsedStartDate, _ := time.Parse("01-01-2006", "01-"+validator.StartDate)
-	parsedEndDate, _ := time.Parse("01-01-2006", "01-"+*validator.EndDate)
+// RestoreSubscriptionById снимает пометку удаления с подписки, если ее версия совпадает с expectedVersion
+// (AnyVersion - без проверки), и возвращает восстановленную подписку
+func (r *PostgresRepository) RestoreSubscriptionById(ctx context.Context, id int, expectedVersion int) (*subscriptions.Subscription, error) {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
 
-	// если end_date is NULL, то считаем что подписка входит в любой диапазон
-	query := `SELECT COALESCE(SUM(price), 0) FROM subscriptions 
-			  WHERE TO_DATE('01-' || start_date, 'DD-MM-YYYY') >= $1
-			  AND (TO_DATE('01-' || end_date, 'DD-MM-YYYY') <= $2 OR end_date IS NULL) `
+	scope, err := scopeFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[RestoreSubscriptionById] %w", err)
+	}
 
-	args := []interface{}{} // массив аргументов к запросу БД
-	args = append(args, parsedStartDate)
-	args = append(args, parsedEndDate)
+	tx, err := r.beginAudited(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[RestoreSubscriptionById] %w", err)
+	}
+	defer tx.Rollback(ctx)
 
-	// добавим фильтрацию к запросу в зависимости от того какие параметры заданы
-	filter := ""
+	sub, err := scanSubscription(tx.QueryRow(ctx, `
+		UPDATE subscriptions
+		SET deleted_at = NULL, version = version + 1
+		WHERE subscription_id = $1 AND ($2 = 0 OR version = $2) AND ($3::uuid IS NULL OR user_id = $3) AND deleted_at IS NOT NULL
+		RETURNING `+subscriptionColumns, id, expectedVersion, scope.arg()))
+	if err == nil {
+		if err := tx.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("[RestoreSubscriptionById|commit] %w", err)
+		}
+		return sub, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("[RestoreSubscriptionById|exec restore sub] %w", err)
+	}
+
+	// ни одна строка не изменилась: записи нет, она не удалена или не совпала версия
+	var deleted bool
+	err = tx.QueryRow(ctx, `SELECT deleted_at IS NOT NULL FROM subscriptions
+		WHERE subscription_id = $1 AND ($2::uuid IS NULL OR user_id = $2)`, id, scope.arg()).Scan(&deleted)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, fmt.Errorf("[RestoreSubscriptionById] %w", ErrSubscriptionDoesNotExist)
+	case err != nil:
+		return nil, fmt.Errorf("[RestoreSubscriptionById|exec check deleted] %w", err)
+	case !deleted:
+		return nil, fmt.Errorf("[RestoreSubscriptionById] %w", ErrSubscriptionNotDeleted)
+	}
+	return nil, fmt.Errorf("[RestoreSubscriptionById] %w", ErrVersionMismatch)
+}
+
+// sortColumns задает выражение для сортировки по полю и
+// выражение для сравнения со значением из курсора
+var sortColumns = map[string]struct{ column, param string }{
+	subscriptions.SortByPrice:       {"price", "%s::bigint"},
+	subscriptions.SortByStartDate:   {"start_date", "%s::date"},
+	subscriptions.SortByServiceName: {"service_name", "%s::text"},
+}
+
+func (r *PostgresRepository) GetAllSubscriptions(ctx context.Context, filter *subscriptions.ListFilter) (*subscriptions.Page, error) {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	where, err := scopedListWhere(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("[GetAllSubscriptions] %w", err)
+	}
+	if err := checkAsOf(ctx, r.pool, filter.AsOf); err != nil {
+		return nil, fmt.Errorf("[GetAllSubscriptions] %w", err)
+	}
 
-	if validator.UserID != uuid.Nil {
-		filter += fmt.Sprintf("AND user_id = $%d ", len(args)+1)
-		args = append(args, validator.UserID)
+	// сравнение строк (поле сортировки, id) для keyset пагинации
+	cmp := ">"
+	if filter.Order == subscriptions.OrderDesc {
+		cmp = "<"
 	}
-	if validator.ServiceName != "" {
-		filter += fmt.Sprintf("AND service_name = $%d", len(args)+1)
-		args = append(args, validator.ServiceName)
+	sort, sorted := sortColumns[filter.SortBy]
+
+	if filter.Cursor != "" {
+		cursor, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("[GetAllSubscriptions] %w", err)
+		}
+		// курсор действителен только для той же сортировки, с которой был выдан
+		if cursor.SortBy != filter.SortBy || cursor.Order != filter.Order {
+			return nil, fmt.Errorf("[GetAllSubscriptions|cursor sort] %w", ErrInvalidCursor)
+		}
+
+		if sorted {
+			value := fmt.Sprintf(sort.param, where.placeholder(cursor.Value))
+			where.addRaw(fmt.Sprintf("(%s, subscription_id) %s (%s, %s)", sort.column, cmp, value, where.placeholder(cursor.ID)))
+		} else {
+			where.add("subscription_id "+cmp+" %s", cursor.ID)
+		}
 	}
 
-	query += filter
+	// запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	query := `SELECT ` + subscriptionColumns + ` FROM ` + subscriptionsFrom(where, filter.AsOf) +
+		where.String() + listOrderBy(filter) + fmt.Sprintf(" LIMIT %d", filter.Limit+1)
 
-	var amount int
-	err := PostgresDB.QueryRow(ctx, query, args...).Scan(&amount)
+	rows, err := r.pool.Query(ctx, query, where.args...)
 	if err != nil {
-		return -1, fmt.Errorf("[GetTotalPriceInPeriod|exec get amount] %w", err)
+		return nil, fmt.Errorf("[GetAllSubscriptions|exec get subs] %w", err)
 	}
-	return amount, nil
+	defer rows.Close()
 
+	page := &subscriptions.Page{Items: []*subscriptions.Subscription{}}
+	for rows.Next() {
+		if len(page.Items) == filter.Limit {
+			// есть следующая страница - выдадим курсор на последнюю запись текущей
+			last := page.Items[len(page.Items)-1]
+			cursor := listCursor{SortBy: filter.SortBy, Order: filter.Order, ID: last.ID}
+			switch filter.SortBy {
+			case subscriptions.SortByPrice:
+				cursor.Value = strconv.FormatInt(int64(last.Price), 10)
+			case subscriptions.SortByStartDate:
+				start, _, _ := last.DateRange()
+				cursor.Value = start.Format(time.DateOnly)
+			case subscriptions.SortByServiceName:
+				cursor.Value = last.ServiceName
+			}
+			page.NextCursor = encodeCursor(cursor)
+			break
+		}
+
+		curSub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("[GetAllSubscriptions|exec get sub] %w", err)
+		}
+
+		page.Items = append(page.Items, curSub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("[GetAllSubscriptions|read rows] %w", err)
+	}
+	return page, nil
 }
 
-func checkExistsSubscription(ctx context.Context, id int) error {
+// checkExistsSubscription проверяет, что подписка существует, не удалена и доступна клиенту;
+// чужие подписки считаются несуществующими, чтобы не раскрывать их id
+func checkExistsSubscription(ctx context.Context, q querier, id int, scope ownerScope) error {
 
 	var exists bool
-	if err := PostgresDB.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM subscriptions WHERE subscription_id = $1)", id).Scan(&exists); err != nil {
+	if err := q.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM subscriptions
+		WHERE subscription_id = $1 AND ($2::uuid IS NULL OR user_id = $2) AND deleted_at IS NULL)`, id, scope.arg()).Scan(&exists); err != nil {
 		return fmt.Errorf("[checkExistsSubscription|exec check exists]: %w", err)
 	}
 
@@ -141,3 +449,52 @@ func checkExistsSubscription(ctx context.Context, id int) error {
 
 	return nil
 }
+
+// listFilterWhere добавляет фильтрацию списка подписок по параметрам выборки; удаленные подписки не выбираются
+func listFilterWhere(filter *subscriptions.ListFilter) *whereBuilder {
+	where := &whereBuilder{}
+	where.addRaw("deleted_at IS NULL")
+	if filter.UserID != uuid.Nil {
+		where.add("user_id = %s", filter.UserID)
+	}
+	if filter.ServiceName != "" {
+		addServiceFilter(where, filter.ServiceName)
+	}
+	if filter.PriceMin != nil {
+		where.add("price >= %s", *filter.PriceMin)
+	}
+	if filter.PriceMax != nil {
+		where.add("price <= %s", *filter.PriceMax)
+	}
+	if filter.ActiveAt != "" {
+		// подписка активна в месяце, если началась не позже него и не закончилась раньше
+		activeAt, _ := subscriptions.ParseMonth(filter.ActiveAt)
+		where.add(`start_date <= %[1]s AND (end_date IS NULL OR end_date >= %[1]s)`, activeAt.Time())
+	}
+	return where
+}
+
+// scopedListWhere строит фильтрацию списка с учетом того, чьи подписки доступны клиенту
+func scopedListWhere(ctx context.Context, filter *subscriptions.ListFilter) (*whereBuilder, error) {
+	scope, err := scopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scoped := *filter
+	if scoped.UserID, err = scope.filterUser(filter.UserID); err != nil {
+		return nil, err
+	}
+	return listFilterWhere(&scoped), nil
+}
+
+// listOrderBy возвращает сортировку списка подписок; subscription_id делает порядок однозначным
+func listOrderBy(filter *subscriptions.ListFilter) string {
+	direction := "ASC"
+	if filter.Order == subscriptions.OrderDesc {
+		direction = "DESC"
+	}
+	if sort, ok := sortColumns[filter.SortBy]; ok {
+		return fmt.Sprintf(" ORDER BY %s %s, subscription_id %s", sort.column, direction, direction)
+	}
+	return fmt.Sprintf(" ORDER BY subscription_id %s", direction)
+}
@@ -6,18 +6,18 @@ import (
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/pgx/v5"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
-	p "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/subscriptions_api/internal/logger"
 )
 
-func RunMigrations(ctx context.Context, conn *p.Conn) error {
+func RunMigrations(ctx context.Context, pool *pgxpool.Pool) error {
 
 	logger.L.Debug("Start processing migrations")
-	db := stdlib.OpenDB(*conn.Config())
+	db := stdlib.OpenDBFromPool(pool)
 	defer db.Close()
 
-	// Создаем драйвер для migrate напрямую из pgx.Conn
+	// Создаем драйвер для migrate поверх пула соединений
 	driver, err := pgx.WithInstance(db, &pgx.Config{})
 	if err != nil {
 		return err
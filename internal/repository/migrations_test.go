@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/subscriptions_api/internal/logger"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+// testMigrations открывает отдельную схему в БД из TEST_DATABASE_URL и возвращает пул соединений с ней
+// и мигратор. Без TEST_DATABASE_URL тест пропускается; схема удаляется после теста
+func testMigrations(t *testing.T) (*pgxpool.Pool, *migrate.Migrate) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	if logger.L == nil {
+		logger.L = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	ctx := context.Background()
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	admin, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(admin.Close)
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+	})
+
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	db := stdlib.OpenDBFromPool(pool)
+	t.Cleanup(func() { db.Close() })
+	driver, err := pgx.WithInstance(db, &pgx.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := migrate.NewWithDatabaseInstance("file://../../migrations", "pgx", driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pool, m
+}
+
+func TestMigrationsDatesToDate(t *testing.T) {
+	pool, m := testMigrations(t)
+	ctx := context.Background()
+	userID := uuid.Must(uuid.NewV4())
+
+	// до 0002 даты хранились строками MM-YYYY
+	if err := m.Migrate(1); err != nil {
+		t.Fatalf("migrate to 1: %v", err)
+	}
+	var closed, open int
+	err := pool.QueryRow(ctx, `INSERT INTO subscriptions (service_name, price, user_id, start_date, end_date)
+		VALUES ('Yandex Plus', 399, $1, '03-2025', '12-2025') RETURNING subscription_id`, userID).Scan(&closed)
+	if err != nil {
+		t.Fatalf("insert before 0002: %v", err)
+	}
+	err = pool.QueryRow(ctx, `INSERT INTO subscriptions (service_name, price, user_id, start_date)
+		VALUES ('Netflix', 999, $1, '11-2024') RETURNING subscription_id`, userID).Scan(&open)
+	if err != nil {
+		t.Fatalf("insert before 0002: %v", err)
+	}
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatalf("migrate up: %v", err)
+	}
+
+	repo := NewPostgresRepository(pool, Timeouts{})
+	tests := []struct {
+		id    int
+		start string
+		end   string
+	}{
+		{id: closed, start: "03-2025", end: "12-2025"},
+		{id: open, start: "11-2024"},
+	}
+	for _, tt := range tests {
+		sub, err := repo.GetSubscriptionById(ctx, tt.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		end := ""
+		if sub.EndDate != nil {
+			end = *sub.EndDate
+		}
+		if sub.StartDate != tt.start || end != tt.end {
+			t.Errorf("subscription %d dates = %s - %q, want %s - %q", tt.id, sub.StartDate, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestMigrationsPriceMinorUnits(t *testing.T) {
+	pool, m := testMigrations(t)
+	ctx := context.Background()
+
+	// до 0010 цены и состояния в журнале хранились в целых единицах
+	if err := m.Migrate(9); err != nil {
+		t.Fatalf("migrate to 9: %v", err)
+	}
+	var id int
+	err := pool.QueryRow(ctx, `INSERT INTO subscriptions (service_name, price, user_id, start_date)
+		VALUES ('Yandex Plus', 399, $1, '2025-01-01') RETURNING subscription_id`, uuid.Must(uuid.NewV4())).Scan(&id)
+	if err != nil {
+		t.Fatalf("insert before 0010: %v", err)
+	}
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatalf("migrate up: %v", err)
+	}
+
+	// журнал не переписывается
+	var stored string
+	if err := pool.QueryRow(ctx, `SELECT after ->> 'price' FROM subscription_events WHERE subscription_id = $1`, id).Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored != "399" {
+		t.Errorf("stored event price = %s, want 399", stored)
+	}
+
+	// при чтении цена из старых событий переводится в минорные единицы
+	repo := NewPostgresRepository(pool, Timeouts{})
+	events, err := repo.GetSubscriptionHistory(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var after struct {
+		Price int64 `json:"price"`
+	}
+	if err := json.Unmarshal(events[0].After, &after); err != nil {
+		t.Fatal(err)
+	}
+	if after.Price != 39900 {
+		t.Errorf("history price = %d, want 39900", after.Price)
+	}
+	sub, err := repo.GetSubscriptionAsOf(ctx, id, currentTime(t, repo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Price != 39900 {
+		t.Errorf("as_of price = %d, want 39900", sub.Price)
+	}
+}
+
+func TestMigrationsKeepOpenEndedSubscriptions(t *testing.T) {
+	pool, m := testMigrations(t)
+	ctx := context.Background()
+	userID := uuid.Must(uuid.NewV4())
+
+	// подписка без даты окончания, существующая до дат с точностью до дня
+	if err := m.Migrate(12); err != nil {
+		t.Fatalf("migrate to 12: %v", err)
+	}
+	if _, err := pool.Exec(ctx, `INSERT INTO subscriptions (service_name, price, user_id, start_date) VALUES ('Yandex Plus', 39900, $1, '2025-01-01')`, userID); err != nil {
+		t.Fatalf("insert before 0013: %v", err)
+	}
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatalf("migrate up: %v", err)
+	}
+
+	repo := NewPostgresRepository(pool, Timeouts{})
+	for _, start := range []string{"02-2025", "2025-02-15"} {
+		sub := &subscriptions.Subscription{ServiceName: "Yandex Plus", Price: 39900, UserID: userID, StartDate: start}
+		if err := subscriptions.Validate(sub); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.CreateSubscription(ctx, sub); err != nil {
+			t.Fatalf("create open-ended subscription from %s: %v", start, err)
+		}
+		got, err := repo.GetSubscriptionById(ctx, sub.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.StartDate != start || got.EndDate != nil {
+			t.Errorf("subscription from %s read back as %s - %v", start, got.StartDate, got.EndDate)
+		}
+	}
+
+	// день окончания по-прежнему проверяется
+	_, err := pool.Exec(ctx, `INSERT INTO subscriptions (service_name, price, user_id, start_date, end_date, end_day) VALUES ('Yandex Plus', 39900, $1, '2025-01-01', '2025-02-01', 30)`, userID)
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != pgerrcode.CheckViolation {
+		t.Errorf("insert with end_day 30 in February: error = %v, want check violation", err)
+	}
+}
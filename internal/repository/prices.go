@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/subscriptions_api/subscriptions"
+)
+
+var (
+	ErrPriceChangeDoesNotExist = errors.New("price change for this month does not exist")
+)
+
+// GetPriceChanges возвращает изменения цены подписки, упорядоченные по месяцу
+func (r *PostgresRepository) GetPriceChanges(ctx context.Context, id int) ([]subscriptions.PriceChange, error) {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	scope, err := scopeFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[GetPriceChanges] %w", err)
+	}
+	if err := checkExistsSubscription(ctx, r.pool, id, scope); err != nil {
+		return nil, fmt.Errorf("[GetPriceChanges] %w", err)
+	}
+
+	rows, err := r.pool.Query(ctx, `SELECT effective_from, price FROM subscription_price_periods
+		WHERE subscription_id = $1 AND superseded_at IS NULL
+		ORDER BY effective_from`, id)
+	if err != nil {
+		return nil, fmt.Errorf("[GetPriceChanges|exec get prices] %w", err)
+	}
+	defer rows.Close()
+
+	changes := []subscriptions.PriceChange{}
+	for rows.Next() {
+		var (
+			change subscriptions.PriceChange
+			from   time.Time
+		)
+		if err := rows.Scan(&from, &change.Price); err != nil {
+			return nil, fmt.Errorf("[GetPriceChanges|scan price] %w", err)
+		}
+		change.Month = subscriptions.MonthOf(from).String()
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("[GetPriceChanges|read rows] %w", err)
+	}
+	return changes, nil
+}
+
+// SchedulePriceChange назначает подписке новую цену с месяца change.Month; изменение на тот же месяц заменяется,
+// прежняя строка остается в истории с отметкой superseded_at. Месяц проверяется по датам подписки под блокировкой строки, чтобы не разойтись с одновременным изменением подписки
+func (r *PostgresRepository) SchedulePriceChange(ctx context.Context, id int, change *subscriptions.PriceChange) error {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	scope, err := scopeFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("[SchedulePriceChange] %w", err)
+	}
+
+	tx, err := r.beginAudited(ctx)
+	if err != nil {
+		return fmt.Errorf("[SchedulePriceChange] %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkExistsSubscription(ctx, tx, id, scope); err != nil {
+		return fmt.Errorf("[SchedulePriceChange] %w", err)
+	}
+	sub, err := scanSubscription(tx.QueryRow(ctx, `SELECT `+subscriptionColumns+`
+		FROM subscriptions
+		WHERE subscription_id = $1
+		FOR UPDATE`, id))
+	if err != nil {
+		return fmt.Errorf("[SchedulePriceChange|exec get sub] %w", err)
+	}
+	if err := subscriptions.ValidatePriceChange(sub, change, subscriptions.MonthOf(time.Now())); err != nil {
+		return fmt.Errorf("[SchedulePriceChange] %w", err)
+	}
+
+	month, _ := subscriptions.ParseMonth(change.Month)
+	if _, err := tx.Exec(ctx, `UPDATE subscription_price_periods SET superseded_at = now()
+		WHERE subscription_id = $1 AND effective_from = $2 AND superseded_at IS NULL`, id, month.Time()); err != nil {
+		return fmt.Errorf("[SchedulePriceChange|exec supersede price] %w", err)
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO subscription_price_periods (subscription_id, effective_from, price)
+		VALUES($1, $2, $3)`, id, month.Time(), change.Price); err != nil {
+		return fmt.Errorf("[SchedulePriceChange|exec insert price] %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("[SchedulePriceChange|commit] %w", err)
+	}
+	return nil
+}
+
+// CancelPriceChange отменяет запланированное изменение цены, помечая его superseded_at;
+// изменения с текущего и прошедших месяцев не отменяются
+func (r *PostgresRepository) CancelPriceChange(ctx context.Context, id int, month subscriptions.Month) error {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	scope, err := scopeFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("[CancelPriceChange] %w", err)
+	}
+	if !month.After(subscriptions.MonthOf(time.Now())) {
+		return fmt.Errorf("[CancelPriceChange] %w", subscriptions.ErrWrongPriceMonth)
+	}
+
+	tx, err := r.beginAudited(ctx)
+	if err != nil {
+		return fmt.Errorf("[CancelPriceChange] %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkExistsSubscription(ctx, tx, id, scope); err != nil {
+		return fmt.Errorf("[CancelPriceChange] %w", err)
+	}
+	tag, err := tx.Exec(ctx, `UPDATE subscription_price_periods SET superseded_at = now()
+		WHERE subscription_id = $1 AND effective_from = $2 AND superseded_at IS NULL`, id, month.Time())
+	if err != nil {
+		return fmt.Errorf("[CancelPriceChange|exec cancel price] %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("[CancelPriceChange] %w", ErrPriceChangeDoesNotExist)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("[CancelPriceChange|commit] %w", err)
+	}
+	return nil
+}
+
+// loadPriceChanges читает изменения цены подписок, выбранных условиями where, по id подписки.
+// С asOf учитываются изменения, назначенные не позже этого момента и не замененные или отмененные к нему
+func loadPriceChanges(ctx context.Context, q querier, where *whereBuilder, asOf *time.Time) (map[int][]subscriptions.PriceChange, error) {
+	query := `SELECT p.subscription_id, p.effective_from, p.price FROM subscription_price_periods p
+		WHERE p.subscription_id IN (SELECT subscription_id FROM ` + subscriptionsFrom(where, asOf) + where.String() + `)`
+	if asOf != nil {
+		at := where.placeholder(*asOf)
+		query += ` AND p.created_at <= ` + at + ` AND (p.superseded_at IS NULL OR p.superseded_at > ` + at + `)`
+	} else {
+		query += ` AND p.superseded_at IS NULL`
+	}
+	rows, err := q.Query(ctx, query, where.args...)
+	if err != nil {
+		return nil, fmt.Errorf("[loadPriceChanges|exec get prices] %w", err)
+	}
+	defer rows.Close()
+
+	changes := map[int][]subscriptions.PriceChange{}
+	for rows.Next() {
+		var (
+			id     int
+			change subscriptions.PriceChange
+			from   time.Time
+		)
+		if err := rows.Scan(&id, &from, &change.Price); err != nil {
+			return nil, fmt.Errorf("[loadPriceChanges|scan price] %w", err)
+		}
+		change.Month = subscriptions.MonthOf(from).String()
+		changes[id] = append(changes[id], change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("[loadPriceChanges|read rows] %w", err)
+	}
+	return changes, nil
+}
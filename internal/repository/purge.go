@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// purgeBatchSize ограничивает число строк, удаляемых одной командой, чтобы не держать долгие блокировки
+const purgeBatchSize = 1000
+
+// PurgeDeletedSubscriptions окончательно удаляет подписки, помеченные удаленными раньше before,
+// и возвращает число удаленных строк
+func (r *PostgresRepository) PurgeDeletedSubscriptions(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Bulk)
+	defer cancel()
+
+	var purged int64
+	for {
+		deleted, err := r.purgeBatch(ctx, before)
+		if err != nil {
+			return purged, fmt.Errorf("[PurgeDeletedSubscriptions] %w", err)
+		}
+		purged += deleted
+		if deleted < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// purgeBatch удаляет не больше purgeBatchSize подписок отдельной транзакцией
+func (r *PostgresRepository) purgeBatch(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := r.beginAudited(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("[purgeBatch] %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `DELETE FROM subscriptions
+		WHERE subscription_id IN (
+			SELECT subscription_id FROM subscriptions
+			WHERE deleted_at < $1
+			LIMIT $2
+		)`, before, purgeBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("[purgeBatch|exec purge] %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("[purgeBatch|commit] %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// whereBuilder собирает условия WHERE вместе с позиционными аргументами запроса
+type whereBuilder struct {
+	conds []string
+	args  []interface{}
+}
+
+// add добавляет условие; в format вместо плейсхолдера подставляется $N для arg
+func (b *whereBuilder) add(format string, arg interface{}) {
+	b.args = append(b.args, arg)
+	b.conds = append(b.conds, fmt.Sprintf(format, fmt.Sprintf("$%d", len(b.args))))
+}
+
+// addRaw добавляет условие без аргументов
+func (b *whereBuilder) addRaw(cond string) {
+	b.conds = append(b.conds, cond)
+}
+
+// placeholder регистрирует аргумент и возвращает его плейсхолдер
+func (b *whereBuilder) placeholder(arg interface{}) string {
+	b.args = append(b.args, arg)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+func (b *whereBuilder) String() string {
+	if len(b.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conds, " AND ")
+}
+
+// listCursor - содержимое непрозрачного курсора постраничной выборки:
+// поле и направление сортировки, значение поля и id последней выданной записи
+type listCursor struct {
+	SortBy string `json:"s,omitempty"`
+	Order  string `json:"o"`
+	Value  string `json:"v,omitempty"`
+	ID     int    `json:"id"`
+}
+
+func encodeCursor(c listCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (listCursor, error) {
+	var c listCursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, fmt.Errorf("[decodeCursor|decode] %w", ErrInvalidCursor)
+	}
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return c, fmt.Errorf("[decodeCursor|unmarshal] %w", ErrInvalidCursor)
+	}
+	return c, nil
+}
+
+// nullIfZero переводит незаданное (нулевое) значение в NULL, как NULLIF($n, 0) в запросах
+func nullIfZero(v int) *int {
+	if v == 0 {
+		return nil
+	}
+	return &v
+}
+
+// zeroIfNull - обратное к nullIfZero преобразование прочитанного из БД значения
+func zeroIfNull(v *int) int {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/subscriptions_api/subscriptions"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []listCursor{
+		{Order: subscriptions.OrderAsc, ID: 1},
+		{SortBy: subscriptions.SortByPrice, Order: subscriptions.OrderDesc, Value: "39999", ID: 42},
+		{SortBy: subscriptions.SortByStartDate, Order: subscriptions.OrderAsc, Value: "2025-01-01", ID: 7},
+		{SortBy: subscriptions.SortByServiceName, Order: subscriptions.OrderAsc, Value: "Яндекс Плюс / 2", ID: 3},
+	}
+	for _, want := range tests {
+		encoded := encodeCursor(want)
+		got, err := decodeCursor(encoded)
+		if err != nil {
+			t.Fatalf("decodeCursor(%q): %v", encoded, err)
+		}
+		if got != want {
+			t.Errorf("decodeCursor(encodeCursor(%+v)) = %+v", want, got)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	for _, in := range []string{"not base64!", "bm90IGpzb24", "eyJpZCI6ImEifQ"} {
+		if _, err := decodeCursor(in); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("decodeCursor(%q) error = %v, want %v", in, err, ErrInvalidCursor)
+		}
+	}
+}
+
+func TestListOrderBy(t *testing.T) {
+	tests := []struct {
+		sortBy string
+		order  string
+		want   string
+	}{
+		{order: subscriptions.OrderAsc, want: " ORDER BY subscription_id ASC"},
+		{order: subscriptions.OrderDesc, want: " ORDER BY subscription_id DESC"},
+		{sortBy: subscriptions.SortByPrice, order: subscriptions.OrderDesc, want: " ORDER BY price DESC, subscription_id DESC"},
+		{sortBy: subscriptions.SortByStartDate, order: subscriptions.OrderAsc, want: " ORDER BY start_date ASC, subscription_id ASC"},
+	}
+	for _, tt := range tests {
+		got := listOrderBy(&subscriptions.ListFilter{SortBy: tt.sortBy, Order: tt.order})
+		if got != tt.want {
+			t.Errorf("listOrderBy(%q, %q) = %q, want %q", tt.sortBy, tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestListFilterWhere(t *testing.T) {
+	priceMin, priceMax := subscriptions.Amount(100), subscriptions.Amount(500)
+	where := listFilterWhere(&subscriptions.ListFilter{PriceMin: &priceMin, PriceMax: &priceMax})
+
+	want := " WHERE deleted_at IS NULL AND price >= $1 AND price <= $2"
+	if got := where.String(); got != want {
+		t.Errorf("where = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(where.args, []interface{}{priceMin, priceMax}) {
+		t.Errorf("args = %v, want [%v %v]", where.args, priceMin, priceMax)
+	}
+}
+
+func TestWhereBuilder(t *testing.T) {
+	var where whereBuilder
+	if got := where.String(); got != "" {
+		t.Errorf("empty where = %q", got)
+	}
+
+	where.add("price >= %s", 100)
+	where.add("price <= %s", 500)
+	want := " WHERE price >= $1 AND price <= $2"
+	if got := where.String(); got != want {
+		t.Errorf("where = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(where.args, []interface{}{100, 500}) {
+		t.Errorf("args = %v, want [100 500]", where.args)
+	}
+
+	// плейсхолдер курсора продолжает нумерацию аргументов фильтра
+	if got := where.placeholder(7); got != "$3" {
+		t.Errorf("placeholder = %q, want $3", got)
+	}
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+// GetRates возвращает действующие курсы валют, упорядоченные по валюте и месяцу начала действия
+func (r *PostgresRepository) GetRates(ctx context.Context) ([]subscriptions.Rate, error) {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	rates, err := getRates(ctx, r.pool, nil)
+	if err != nil {
+		return nil, fmt.Errorf("[GetRates] %w", err)
+	}
+	return rates, nil
+}
+
+// getRates читает последнюю версию курса каждой валюты на каждый месяц;
+// с asOf - последнюю версию, загруженную не позже этого момента
+func getRates(ctx context.Context, q querier, asOf *time.Time) ([]subscriptions.Rate, error) {
+	where := &whereBuilder{}
+	if asOf != nil {
+		where.add("created_at <= %s", *asOf)
+	}
+	// курс читаем строкой, чтобы не терять точность NUMERIC
+	query := `SELECT DISTINCT ON (currency, effective_from) currency, effective_from, rate::text FROM currency_rates` +
+		where.String() + ` ORDER BY currency, effective_from, created_at DESC, rate_id DESC`
+	rows, err := q.Query(ctx, query, where.args...)
+	if err != nil {
+		return nil, fmt.Errorf("[getRates|exec get rates] %w", err)
+	}
+	defer rows.Close()
+
+	rates := []subscriptions.Rate{}
+	for rows.Next() {
+		var (
+			rate subscriptions.Rate
+			from time.Time
+		)
+		if err := rows.Scan(&rate.Currency, &from, &rate.Rate); err != nil {
+			return nil, fmt.Errorf("[getRates|scan rate] %w", err)
+		}
+		rate.Month = subscriptions.MonthOf(from).String()
+		rates = append(rates, rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("[getRates|read rows] %w", err)
+	}
+	return rates, nil
+}
+
+// loadRates читает курсы для пересчета сумм в том виде, в котором они были известны в момент asOf; nil - текущие
+func loadRates(ctx context.Context, q querier, asOf *time.Time) (*subscriptions.Rates, error) {
+	rates, err := getRates(ctx, q, asOf)
+	if err != nil {
+		return nil, err
+	}
+	return subscriptions.NewRates(rates)
+}
+
+// UpsertRates добавляет курсы валют или заменяет курсы на те же месяцы. Таблица курсов только дополняется:
+// замена записывается новой версией, а прежняя остается для подсчета на момент as_of.
+// Изменять курсы может только администратор
+func (r *PostgresRepository) UpsertRates(ctx context.Context, rates []subscriptions.Rate) error {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	if err := checkAdmin(ctx); err != nil {
+		return fmt.Errorf("[UpsertRates] %w", err)
+	}
+
+	batch := &pgx.Batch{}
+	for i := range rates {
+		month, err := subscriptions.ParseMonth(rates[i].Month)
+		if err != nil {
+			return fmt.Errorf("[UpsertRates|month] %w", err)
+		}
+		batch.Queue(`INSERT INTO currency_rates (currency, effective_from, rate)
+			VALUES($1, $2, $3::numeric)`,
+			rates[i].Currency, month.Time(), rates[i].Rate)
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("[UpsertRates|begin] %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("[UpsertRates|exec upsert rates] %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("[UpsertRates|commit] %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+// SubscriptionRepository описывает хранилище записей о подписках
+type SubscriptionRepository interface {
+	CreateSubscription(ctx context.Context, sub *subscriptions.Subscription) error
+	GetSubscriptionById(ctx context.Context, id int) (*subscriptions.Subscription, error)
+	GetSubscriptionAsOf(ctx context.Context, id int, asOf time.Time) (*subscriptions.Subscription, error)
+	UpdateSubscriptionById(ctx context.Context, id int, expectedVersion int, sub *subscriptions.Subscription) error
+	PatchSubscriptionById(ctx context.Context, id int, expectedVersion int, patch func(sub *subscriptions.Subscription) (*subscriptions.Subscription, error)) (*subscriptions.Subscription, error)
+	DeleteSubscriptionById(ctx context.Context, id int, expectedVersion int) error
+	RestoreSubscriptionById(ctx context.Context, id int, expectedVersion int) (*subscriptions.Subscription, error)
+	GetSubscriptionHistory(ctx context.Context, id int) ([]subscriptions.Event, error)
+	ApplyBatch(ctx context.Context, items []*subscriptions.BatchItem, atomic bool) ([]error, error)
+	ImportSubscriptions(ctx context.Context, next func() (*subscriptions.Subscription, error)) (int64, error)
+	GetAllSubscriptions(ctx context.Context, filter *subscriptions.ListFilter) (*subscriptions.Page, error)
+	ExportSubscriptions(ctx context.Context, filter *subscriptions.ListFilter) (*SubscriptionExport, error)
+	GetPriceChanges(ctx context.Context, id int) ([]subscriptions.PriceChange, error)
+	SchedulePriceChange(ctx context.Context, id int, change *subscriptions.PriceChange) error
+	CancelPriceChange(ctx context.Context, id int, month subscriptions.Month) error
+	GetTotalPriceInPeriod(ctx context.Context, filter *subscriptions.TotalFilter) (subscriptions.Amount, error)
+	GetMonthlyBreakdown(ctx context.Context, filter *subscriptions.TotalFilter, groupBy string) ([]subscriptions.SeriesGroup, error)
+	GetForecast(ctx context.Context, filter *subscriptions.ForecastFilter, now time.Time) (*subscriptions.Forecast, error)
+	GetRates(ctx context.Context) ([]subscriptions.Rate, error)
+	UpsertRates(ctx context.Context, rates []subscriptions.Rate) error
+	GetAllServices(ctx context.Context) ([]*subscriptions.Service, error)
+	GetServiceById(ctx context.Context, id int) (*subscriptions.Service, error)
+	CreateService(ctx context.Context, svc *subscriptions.Service) error
+	UpdateServiceById(ctx context.Context, id int, svc *subscriptions.Service) error
+	DeleteServiceById(ctx context.Context, id int) error
+	MergeServices(ctx context.Context, id int, from int) (*subscriptions.Service, error)
+}
+
+// Timeouts задает предельное время выполнения запросов к БД по типам операций.
+// Нулевое значение означает отсутствие собственного таймаута
+type Timeouts struct {
+	Read      time.Duration
+	Write     time.Duration
+	Aggregate time.Duration
+	Bulk      time.Duration
+}
+
+// querier - общее подмножество методов пула соединений и транзакции
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// PostgresRepository - реализация SubscriptionRepository поверх пула соединений Postgres
+type PostgresRepository struct {
+	pool     *pgxpool.Pool
+	timeouts Timeouts
+}
+
+var _ SubscriptionRepository = (*PostgresRepository)(nil)
+
+func NewPostgresRepository(pool *pgxpool.Pool, timeouts Timeouts) *PostgresRepository {
+	return &PostgresRepository{pool: pool, timeouts: timeouts}
+}
+
+// withTimeout ограничивает контекст запроса таймаутом операции
+func (r *PostgresRepository) withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
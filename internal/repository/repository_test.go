@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithTimeout(t *testing.T) {
+	r := NewPostgresRepository(nil, Timeouts{})
+
+	// нулевой таймаут не ограничивает запрос
+	ctx, cancel := r.withTimeout(context.Background(), 0)
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("withTimeout(0) set a deadline")
+	}
+	cancel()
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Errorf("ctx.Err() after cancel = %v, want %v", ctx.Err(), context.Canceled)
+	}
+
+	start := time.Now()
+	ctx, cancel = r.withTimeout(context.Background(), time.Second)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || deadline.Before(start.Add(time.Second)) || deadline.After(time.Now().Add(time.Second)) {
+		t.Errorf("withTimeout(1s) deadline = %v, %v", deadline, ok)
+	}
+
+	// отмена запроса клиентом прерывает и запрос к БД
+	parent, cancelParent := context.WithCancel(context.Background())
+	ctx, cancel = r.withTimeout(parent, time.Minute)
+	defer cancel()
+	cancelParent()
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Errorf("ctx.Err() after parent cancel = %v, want %v", ctx.Err(), context.Canceled)
+	}
+
+	// таймаут запроса короче таймаута операции
+	parent, cancelParent = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancelParent()
+	ctx, cancel = r.withTimeout(parent, time.Minute)
+	defer cancel()
+	<-ctx.Done()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Errorf("ctx.Err() = %v, want %v", ctx.Err(), context.DeadlineExceeded)
+	}
+}
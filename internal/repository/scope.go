@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofrs/uuid"
+	"github.com/subscriptions_api/internal/auth"
+)
+
+// ownerScope ограничивает запросы подписками одного пользователя.
+// Нулевое значение - без ограничений: для администраторов и вызовов без аутентифицированного клиента
+// (аутентификация выключена, подкоманды CLI)
+type ownerScope struct {
+	userID     uuid.UUID
+	restricted bool
+}
+
+// scopeFromContext определяет ограничение по клиенту из контекста запроса.
+// Клиент с ролью user без user_id не имеет доступа ни к одной подписке
+func scopeFromContext(ctx context.Context) (ownerScope, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok || principal.IsAdmin() {
+		return ownerScope{}, nil
+	}
+	if principal.UserID == uuid.Nil {
+		return ownerScope{}, fmt.Errorf("[scopeFromContext|no user id] %w", auth.ErrForbidden)
+	}
+	return ownerScope{userID: principal.UserID, restricted: true}, nil
+}
+
+// arg - аргумент для условия ($N::uuid IS NULL OR user_id = $N): NULL снимает ограничение
+func (s ownerScope) arg() *uuid.UUID {
+	if !s.restricted {
+		return nil
+	}
+	return &s.userID
+}
+
+// check проверяет, что клиент может записать подписку с этим user_id
+func (s ownerScope) check(userID uuid.UUID) error {
+	if s.restricted && userID != s.userID {
+		return fmt.Errorf("[ownerScope|user %s] %w", userID, auth.ErrForbidden)
+	}
+	return nil
+}
+
+// filterUser возвращает user_id для фильтра выборки: клиент с ограничением всегда видит только свои подписки,
+// а запрос чужого user_id запрещен
+func (s ownerScope) filterUser(requested uuid.UUID) (uuid.UUID, error) {
+	if !s.restricted {
+		return requested, nil
+	}
+	if err := s.check(requested); requested != uuid.Nil && err != nil {
+		return uuid.Nil, err
+	}
+	return s.userID, nil
+}
+
+// checkAdmin разрешает изменение справочников только клиентам без ограничения по пользователю
+func checkAdmin(ctx context.Context) error {
+	scope, err := scopeFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if scope.restricted {
+		return fmt.Errorf("[checkAdmin] %w", auth.ErrForbidden)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/subscriptions_api/internal/auth"
+)
+
+var (
+	ownerID = uuid.Must(uuid.FromString("60601fee-2bf1-4721-ae6f-7636e79a0cba"))
+	otherID = uuid.Must(uuid.FromString("0e4b1cf4-6d8c-4b8f-9d6a-3f1f6c2b7a10"))
+)
+
+func TestScopeFromContext(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		want      ownerScope
+		err       error
+	}{
+		{name: "no principal", want: ownerScope{}},
+		{name: "system", principal: auth.System("cli"), want: ownerScope{}},
+		{name: "admin with user id", principal: &auth.Principal{UserID: ownerID, Role: auth.RoleAdmin}, want: ownerScope{}},
+		{name: "user", principal: &auth.Principal{UserID: ownerID, Role: auth.RoleUser}, want: ownerScope{userID: ownerID, restricted: true}},
+		{name: "user without user id", principal: &auth.Principal{Subject: "billing", Role: auth.RoleUser}, err: auth.ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, tt.principal)
+			}
+			scope, err := scopeFromContext(ctx)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("scopeFromContext error = %v, want %v", err, tt.err)
+			}
+			if scope != tt.want {
+				t.Errorf("scopeFromContext = %+v, want %+v", scope, tt.want)
+			}
+		})
+	}
+}
+
+func TestOwnerScope(t *testing.T) {
+	unrestricted := ownerScope{}
+	restricted := ownerScope{userID: ownerID, restricted: true}
+
+	if arg := unrestricted.arg(); arg != nil {
+		t.Errorf("unrestricted arg = %v, want nil", arg)
+	}
+	if arg := restricted.arg(); arg == nil || *arg != ownerID {
+		t.Errorf("restricted arg = %v, want %s", arg, ownerID)
+	}
+
+	tests := []struct {
+		name      string
+		scope     ownerScope
+		requested uuid.UUID
+		filter    uuid.UUID
+		err       error
+	}{
+		{name: "unrestricted any user", scope: unrestricted, requested: otherID, filter: otherID},
+		{name: "unrestricted all users", scope: unrestricted, requested: uuid.Nil, filter: uuid.Nil},
+		{name: "restricted own user", scope: restricted, requested: ownerID, filter: ownerID},
+		{name: "restricted all users", scope: restricted, requested: uuid.Nil, filter: ownerID},
+		{name: "restricted other user", scope: restricted, requested: otherID, err: auth.ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := tt.scope.filterUser(tt.requested)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("filterUser error = %v, want %v", err, tt.err)
+			}
+			if filter != tt.filter {
+				t.Errorf("filterUser = %s, want %s", filter, tt.filter)
+			}
+
+			// записать подписку можно только с тем user_id, который клиент может запросить
+			wantCheck := tt.err
+			if tt.requested == uuid.Nil && tt.scope.restricted {
+				wantCheck = auth.ErrForbidden
+			}
+			if err := tt.scope.check(tt.requested); !errors.Is(err, wantCheck) {
+				t.Errorf("check error = %v, want %v", err, wantCheck)
+			}
+		})
+	}
+}
+
+func TestCheckAdmin(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		err       error
+	}{
+		{name: "no principal"},
+		{name: "admin", principal: &auth.Principal{Role: auth.RoleAdmin}},
+		{name: "user", principal: &auth.Principal{UserID: ownerID, Role: auth.RoleUser}, err: auth.ErrForbidden},
+		{name: "user without user id", principal: &auth.Principal{Role: auth.RoleUser}, err: auth.ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = auth.WithPrincipal(ctx, tt.principal)
+			}
+			if err := checkAdmin(ctx); !errors.Is(err, tt.err) {
+				t.Errorf("checkAdmin error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
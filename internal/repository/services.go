@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+var (
+	ErrServiceDoesNotExist = errors.New("service with this id does not exist")
+)
+
+// serviceColumns выбирает сервис вместе с синонимами, кроме канонического названия
+const serviceColumns = `s.service_id, s.name, COALESCE(s.category, ''),
+	COALESCE(array_agg(a.alias ORDER BY a.alias) FILTER (WHERE a.alias_key <> service_key(s.name)), '{}')
+	FROM services s LEFT JOIN service_aliases a USING (service_id)`
+
+func scanService(row pgx.Row) (*subscriptions.Service, error) {
+	var svc subscriptions.Service
+	if err := row.Scan(&svc.ID, &svc.Name, &svc.Category, &svc.Aliases); err != nil {
+		return nil, err
+	}
+	return &svc, nil
+}
+
+// addServiceFilter фильтрует подписки по сервису, найденному по названию или синониму
+func addServiceFilter(where *whereBuilder, name string) {
+	where.add("service_id = (SELECT service_id FROM service_aliases WHERE alias_key = service_key(%s))", name)
+}
+
+// GetAllServices возвращает справочник сервисов, упорядоченный по названию
+func (r *PostgresRepository) GetAllServices(ctx context.Context) ([]*subscriptions.Service, error) {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	rows, err := r.pool.Query(ctx, `SELECT `+serviceColumns+` GROUP BY s.service_id ORDER BY s.name, s.service_id`)
+	if err != nil {
+		return nil, fmt.Errorf("[GetAllServices|exec get services] %w", err)
+	}
+	defer rows.Close()
+
+	services := []*subscriptions.Service{}
+	for rows.Next() {
+		svc, err := scanService(rows)
+		if err != nil {
+			return nil, fmt.Errorf("[GetAllServices|scan service] %w", err)
+		}
+		services = append(services, svc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("[GetAllServices|read rows] %w", err)
+	}
+	return services, nil
+}
+
+func (r *PostgresRepository) GetServiceById(ctx context.Context, id int) (*subscriptions.Service, error) {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	svc, err := getService(ctx, r.pool, id)
+	if err != nil {
+		return nil, fmt.Errorf("[GetServiceById] %w", err)
+	}
+	return svc, nil
+}
+
+func getService(ctx context.Context, q querier, id int) (*subscriptions.Service, error) {
+	svc, err := scanService(q.QueryRow(ctx, `SELECT `+serviceColumns+` WHERE s.service_id = $1 GROUP BY s.service_id`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrServiceDoesNotExist
+	}
+	if err != nil {
+		return nil, fmt.Errorf("[getService|exec get service] %w", err)
+	}
+	return svc, nil
+}
+
+// CreateService добавляет сервис в справочник и проставляет в svc сгенерированный id.
+// Название или синоним, уже принадлежащие другому сервису, дают ошибку уникальности
+func (r *PostgresRepository) CreateService(ctx context.Context, svc *subscriptions.Service) error {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	if err := checkAdmin(ctx); err != nil {
+		return fmt.Errorf("[CreateService] %w", err)
+	}
+
+	tx, err := r.beginAudited(ctx)
+	if err != nil {
+		return fmt.Errorf("[CreateService] %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `INSERT INTO services (name, category) VALUES($1, NULLIF($2, ''))
+		RETURNING service_id`, svc.Name, svc.Category).Scan(&svc.ID)
+	if err != nil {
+		return fmt.Errorf("[CreateService|exec insert service] %w", err)
+	}
+	if err := replaceAliases(ctx, tx, svc.ID, append([]string{svc.Name}, svc.Aliases...)); err != nil {
+		return fmt.Errorf("[CreateService] %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("[CreateService|commit] %w", err)
+	}
+	return nil
+}
+
+// UpdateServiceById перезаписывает сервис и его синонимы. Прежнее название остается синонимом,
+// а подписки сервиса получают новое каноническое название
+func (r *PostgresRepository) UpdateServiceById(ctx context.Context, id int, svc *subscriptions.Service) error {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	if err := checkAdmin(ctx); err != nil {
+		return fmt.Errorf("[UpdateServiceById] %w", err)
+	}
+
+	tx, err := r.beginAudited(ctx)
+	if err != nil {
+		return fmt.Errorf("[UpdateServiceById] %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var oldName string
+	err = tx.QueryRow(ctx, `SELECT name FROM services WHERE service_id = $1 FOR UPDATE`, id).Scan(&oldName)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("[UpdateServiceById] %w", ErrServiceDoesNotExist)
+	}
+	if err != nil {
+		return fmt.Errorf("[UpdateServiceById|exec get service] %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE services SET name = $1, category = NULLIF($2, '') WHERE service_id = $3`,
+		svc.Name, svc.Category, id)
+	if err != nil {
+		return fmt.Errorf("[UpdateServiceById|exec update service] %w", err)
+	}
+	names := append([]string{svc.Name}, svc.Aliases...)
+	if !containsServiceName(names, oldName) {
+		names = append(names, oldName)
+	}
+	if err := replaceAliases(ctx, tx, id, names); err != nil {
+		return fmt.Errorf("[UpdateServiceById] %w", err)
+	}
+
+	// новое название подписки найдут по синониму - это тот же сервис
+	_, err = tx.Exec(ctx, `UPDATE subscriptions SET service_name = $1, version = version + 1
+		WHERE service_id = $2 AND service_name <> $1`, svc.Name, id)
+	if err != nil {
+		return fmt.Errorf("[UpdateServiceById|exec rename subs] %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("[UpdateServiceById|commit] %w", err)
+	}
+
+	svc.ID = id
+	svc.Aliases = names[1:]
+	return nil
+}
+
+// MergeServices сливает сервис from с сервисом id: названия и синонимы from становятся синонимами id,
+// подписки from (в том числе удаленные) переходят к id с его каноническим названием, а сервис from удаляется
+func (r *PostgresRepository) MergeServices(ctx context.Context, id int, from int) (*subscriptions.Service, error) {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	if err := checkAdmin(ctx); err != nil {
+		return nil, fmt.Errorf("[MergeServices] %w", err)
+	}
+
+	tx, err := r.beginAudited(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[MergeServices] %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// блокируем оба сервиса, чтобы параллельное изменение справочника не разошлось со слиянием
+	var locked int
+	err = tx.QueryRow(ctx, `SELECT count(*) FROM (
+		SELECT service_id FROM services WHERE service_id IN ($1, $2) ORDER BY service_id FOR UPDATE
+	) s`, id, from).Scan(&locked)
+	if err != nil {
+		return nil, fmt.Errorf("[MergeServices|exec lock services] %w", err)
+	}
+	if locked != 2 {
+		return nil, fmt.Errorf("[MergeServices] %w", ErrServiceDoesNotExist)
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE service_aliases SET service_id = $1 WHERE service_id = $2`, id, from); err != nil {
+		return nil, fmt.Errorf("[MergeServices|exec move aliases] %w", err)
+	}
+	// название подписок заменит триггер БД по новому service_id
+	_, err = tx.Exec(ctx, `UPDATE subscriptions SET service_id = $1, version = version + 1 WHERE service_id = $2`, id, from)
+	if err != nil {
+		return nil, fmt.Errorf("[MergeServices|exec move subs] %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM services WHERE service_id = $1`, from); err != nil {
+		return nil, fmt.Errorf("[MergeServices|exec delete service] %w", err)
+	}
+
+	svc, err := getService(ctx, tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("[MergeServices] %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("[MergeServices|commit] %w", err)
+	}
+	return svc, nil
+}
+
+// DeleteServiceById удаляет сервис из справочника; сервис, на который ссылаются подписки
+// (в том числе удаленные), удалить нельзя - БД вернет ошибку внешнего ключа; такой сервис можно слить с другим, см. MergeServices
+func (r *PostgresRepository) DeleteServiceById(ctx context.Context, id int) error {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	if err := checkAdmin(ctx); err != nil {
+		return fmt.Errorf("[DeleteServiceById] %w", err)
+	}
+
+	tag, err := r.pool.Exec(ctx, `DELETE FROM services WHERE service_id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("[DeleteServiceById|exec delete service] %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("[DeleteServiceById] %w", ErrServiceDoesNotExist)
+	}
+	return nil
+}
+
+// containsServiceName проверяет, есть ли среди названий совпадающее с name без учета регистра и пробелов
+func containsServiceName(names []string, name string) bool {
+	for _, n := range names {
+		if subscriptions.ServiceKey(n) == subscriptions.ServiceKey(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceAliases заменяет названия, по которым находится сервис
+func replaceAliases(ctx context.Context, tx pgx.Tx, id int, names []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM service_aliases WHERE service_id = $1`, id); err != nil {
+		return fmt.Errorf("[replaceAliases|exec delete aliases] %w", err)
+	}
+	_, err := tx.Exec(ctx, `INSERT INTO service_aliases (alias_key, service_id, alias)
+		SELECT DISTINCT ON (service_key(name)) service_key(name), $1, name
+		FROM unnest($2::text[]) WITH ORDINALITY AS n(name, ord)
+		ORDER BY service_key(name), ord`, id, names)
+	if err != nil {
+		return fmt.Errorf("[replaceAliases|exec insert aliases] %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+func TestServicesMigration(t *testing.T) {
+	pool, m := testMigrations(t)
+	ctx := context.Background()
+	userID := uuid.Must(uuid.NewV4())
+
+	// до справочника названия одного сервиса записаны по-разному
+	if err := m.Migrate(10); err != nil {
+		t.Fatalf("migrate to 10: %v", err)
+	}
+	ids := map[string]int{}
+	for _, name := range []string{"yandex plus", "yandex plus", "Yandex Plus", "Netflix"} {
+		var id int
+		err := pool.QueryRow(ctx, `INSERT INTO subscriptions (service_name, price, user_id, start_date)
+			VALUES ($1, 39900, $2, '2025-01-01') RETURNING subscription_id`, name, userID).Scan(&id)
+		if err != nil {
+			t.Fatalf("insert %q: %v", name, err)
+		}
+		ids[name] = id
+	}
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatalf("migrate up: %v", err)
+	}
+	repo := NewPostgresRepository(pool, Timeouts{})
+
+	// каноническим становится самое частое написание
+	renamed, err := repo.GetSubscriptionById(ctx, ids["Yandex Plus"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := repo.GetSubscriptionById(ctx, ids["yandex plus"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.ServiceName != "yandex plus" || renamed.ServiceID != kept.ServiceID || renamed.Version != 2 || kept.Version != 1 {
+		t.Errorf("migrated subscriptions = %+v, %+v", renamed, kept)
+	}
+
+	// в журнале остается только замена названия, записанная от имени migration
+	events, err := repo.GetSubscriptionHistory(ctx, ids["Yandex Plus"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := events[len(events)-1]
+	if last.Operation != subscriptions.EventUpdate || last.Actor != "migration" {
+		t.Errorf("renamed subscription last event = %s by %s, want update by migration", last.Operation, last.Actor)
+	}
+	for _, name := range []string{"yandex plus", "Netflix"} {
+		events, err := repo.GetSubscriptionHistory(ctx, ids[name])
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range events {
+			if e.Operation == subscriptions.EventUpdate {
+				t.Errorf("subscription %q has migration update event by %s", name, e.Actor)
+			}
+		}
+	}
+}
+
+func TestServiceAliases(t *testing.T) {
+	pool, m := testMigrations(t)
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatalf("migrate up: %v", err)
+	}
+	repo := NewPostgresRepository(pool, Timeouts{})
+	ctx := context.Background()
+
+	svc := &subscriptions.Service{Name: "Yandex Plus", Aliases: []string{"Яндекс Плюс"}}
+	if err := subscriptions.ValidateService(svc); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CreateService(ctx, svc); err != nil {
+		t.Fatalf("CreateService: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		sub       subscriptions.Subscription
+		serviceID int
+		want      string
+	}{
+		{name: "alias", sub: subscriptions.Subscription{ServiceName: "яндекс   плюс"}, serviceID: svc.ID, want: "Yandex Plus"},
+		{name: "service id", sub: subscriptions.Subscription{ServiceID: svc.ID}, serviceID: svc.ID, want: "Yandex Plus"},
+		// неизвестное название регистрируется как новый сервис
+		{name: "new service", sub: subscriptions.Subscription{ServiceName: "Kion"}, want: "Kion"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := tt.sub
+			sub.Price, sub.UserID, sub.StartDate = 39900, uuid.Must(uuid.NewV4()), "01-2025"
+			if err := subscriptions.Validate(&sub); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.CreateSubscription(ctx, &sub); err != nil {
+				t.Fatalf("CreateSubscription: %v", err)
+			}
+			got, err := repo.GetSubscriptionById(ctx, sub.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.ServiceName != tt.want || (tt.serviceID != 0 && got.ServiceID != tt.serviceID) || got.ServiceID == 0 {
+				t.Errorf("subscription service = %d %q, want %d %q", got.ServiceID, got.ServiceName, tt.serviceID, tt.want)
+			}
+		})
+	}
+
+	// название другого сервиса нельзя сделать синонимом
+	other := &subscriptions.Service{Name: "Plus", Aliases: []string{"YANDEX PLUS"}}
+	if err := repo.CreateService(ctx, other); err == nil {
+		t.Error("CreateService with another service name as alias succeeded")
+	}
+}
@@ -0,0 +1,324 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+func (r *PostgresRepository) GetTotalPriceInPeriod(ctx context.Context, filter *subscriptions.TotalFilter) (subscriptions.Amount, error) {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Aggregate)
+	defer cancel()
+
+	filter, err := scopedTotalFilter(ctx, filter)
+	if err != nil {
+		return -1, fmt.Errorf("[GetTotalPriceInPeriod] %w", err)
+	}
+	tx, err := r.beginSnapshot(ctx)
+	if err != nil {
+		return -1, fmt.Errorf("[GetTotalPriceInPeriod] %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkAsOf(ctx, tx, filter.AsOf); err != nil {
+		return -1, fmt.Errorf("[GetTotalPriceInPeriod] %w", err)
+	}
+	period := filter.Period()
+
+	rates, err := loadRates(ctx, tx, filter.AsOf)
+	if err != nil {
+		return -1, fmt.Errorf("[GetTotalPriceInPeriod] %w", err)
+	}
+
+	if filter.Mode == subscriptions.TotalModeContained {
+		amount, err := containedTotal(ctx, tx, filter, rates)
+		if err != nil {
+			return -1, fmt.Errorf("[GetTotalPriceInPeriod] %w", err)
+		}
+		return amount, nil
+	}
+
+	// считаем стоимость месяцев каждой подписки, попавших в период, по курсу каждого месяца
+	breakdown := subscriptions.NewBreakdown(period, filter.Currency, rates, filter.Basis())
+	err = forEachOverlapping(ctx, tx, filter, func(sub *subscriptions.Subscription) error {
+		if err := breakdown.Add(sub); err != nil {
+			return fmt.Errorf("[overlap sub %d] %w", sub.ID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return -1, fmt.Errorf("[GetTotalPriceInPeriod] %w", err)
+	}
+	amount, err := breakdown.Total()
+	if err != nil {
+		return -1, fmt.Errorf("[GetTotalPriceInPeriod] %w", err)
+	}
+	return amount, nil
+}
+
+// containedTotal суммирует цены подписок, целиком лежащих в периоде фильтра.
+// Цена подписки учитывается один раз по курсу месяца ее начала; изменения цены действуют только
+// с месяцев после начала подписки, поэтому в этом режиме не учитываются
+func containedTotal(ctx context.Context, q querier, filter *subscriptions.TotalFilter, rates *subscriptions.Rates) (subscriptions.Amount, error) {
+	period := filter.Period()
+	// подписка целиком лежит в периоде; если end_date is NULL, то считаем что подписка входит в любой диапазон
+	where := totalFilterWhere(filter)
+	where.add("start_date >= %s", period.Start.Time())
+	where.add("(end_date <= %s OR end_date IS NULL)", period.End.Time())
+
+	// суммы по валюте и месяцу начала, чтобы пересчитать каждую по своему курсу;
+	// SUM по BIGINT возвращает NUMERIC и читается строкой, чтобы не переполнить int64
+	query := `SELECT currency, start_date, SUM(price)::text FROM ` + subscriptionsFrom(where, filter.AsOf) + where.String() +
+		` GROUP BY currency, start_date`
+	rows, err := q.Query(ctx, query, where.args...)
+	if err != nil {
+		return 0, fmt.Errorf("[containedTotal|exec get amounts] %w", err)
+	}
+	defer rows.Close()
+
+	amount := new(big.Rat)
+	for rows.Next() {
+		var (
+			currency string
+			start    time.Time
+			sumText  string
+		)
+		if err := rows.Scan(&currency, &start, &sumText); err != nil {
+			return 0, fmt.Errorf("[containedTotal|scan amount] %w", err)
+		}
+		sum, ok := new(big.Rat).SetString(sumText)
+		if !ok {
+			return 0, fmt.Errorf("[containedTotal|parse sum %q] %w", sumText, subscriptions.ErrWrongAmount)
+		}
+		ratio, err := rates.Ratio(currency, filter.Currency, subscriptions.MonthOf(start))
+		if err != nil {
+			return 0, fmt.Errorf("[containedTotal] %w", err)
+		}
+		amount.Add(amount, sum.Mul(sum, ratio))
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("[containedTotal|read rows] %w", err)
+	}
+	total, err := subscriptions.RoundAmount(amount)
+	if err != nil {
+		return 0, fmt.Errorf("[containedTotal] %w", err)
+	}
+	return total, nil
+}
+
+// GetMonthlyBreakdown возвращает помесячную разбивку стоимости подписок за период.
+// Без группировки возвращается одна серия с пустым именем группы
+func (r *PostgresRepository) GetMonthlyBreakdown(ctx context.Context, filter *subscriptions.TotalFilter, groupBy string) ([]subscriptions.SeriesGroup, error) {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Aggregate)
+	defer cancel()
+
+	filter, err := scopedTotalFilter(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("[GetMonthlyBreakdown] %w", err)
+	}
+	tx, err := r.beginSnapshot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[GetMonthlyBreakdown] %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkAsOf(ctx, tx, filter.AsOf); err != nil {
+		return nil, fmt.Errorf("[GetMonthlyBreakdown] %w", err)
+	}
+	period := filter.Period()
+	rates, err := loadRates(ctx, tx, filter.AsOf)
+	if err != nil {
+		return nil, fmt.Errorf("[GetMonthlyBreakdown] %w", err)
+	}
+	groups := map[string]*subscriptions.Breakdown{}
+	if groupBy == "" {
+		groups[""] = subscriptions.NewBreakdown(period, filter.Currency, rates, filter.Basis())
+	}
+
+	err = forEachOverlapping(ctx, tx, filter, func(sub *subscriptions.Subscription) error {
+		var key string
+		switch groupBy {
+		case subscriptions.GroupByServiceName:
+			key = sub.ServiceName
+		case subscriptions.GroupByUserID:
+			key = sub.UserID.String()
+		}
+
+		breakdown, ok := groups[key]
+		if !ok {
+			breakdown = subscriptions.NewBreakdown(period, filter.Currency, rates, filter.Basis())
+			groups[key] = breakdown
+		}
+		if err := breakdown.Add(sub); err != nil {
+			return fmt.Errorf("[breakdown sub %d] %w", sub.ID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("[GetMonthlyBreakdown] %w", err)
+	}
+
+	result := make([]subscriptions.SeriesGroup, 0, len(groups))
+	for key, breakdown := range groups {
+		series, err := breakdown.Series()
+		if err != nil {
+			return nil, fmt.Errorf("[GetMonthlyBreakdown|group %q] %w", key, err)
+		}
+		result = append(result, subscriptions.SeriesGroup{Group: key, Series: series})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Group < result[j].Group })
+	return result, nil
+}
+
+// GetForecast прогнозирует фактические списания подписок после now по периодам списания и
+// запланированным изменениям цены; суммы возвращаются по месяцам и по сервисам
+func (r *PostgresRepository) GetForecast(ctx context.Context, forecast *subscriptions.ForecastFilter, now time.Time) (*subscriptions.Forecast, error) {
+	ctx, cancel := r.withTimeout(ctx, r.timeouts.Aggregate)
+	defer cancel()
+
+	from, to := forecast.Window(now)
+	filter, err := scopedTotalFilter(ctx, forecast.TotalFilter(from, to))
+	if err != nil {
+		return nil, fmt.Errorf("[GetForecast] %w", err)
+	}
+	tx, err := r.beginSnapshot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[GetForecast] %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	period := filter.Period()
+	rates, err := loadRates(ctx, tx, filter.AsOf)
+	if err != nil {
+		return nil, fmt.Errorf("[GetForecast] %w", err)
+	}
+
+	total := subscriptions.NewBreakdown(period, filter.Currency, rates, subscriptions.ChargeActual)
+	total.SetFirstDay(from.Day)
+	services := map[string]*subscriptions.Breakdown{}
+	err = forEachOverlapping(ctx, tx, filter, func(sub *subscriptions.Subscription) error {
+		breakdown, ok := services[sub.ServiceName]
+		if !ok {
+			breakdown = subscriptions.NewBreakdown(period, filter.Currency, rates, subscriptions.ChargeActual)
+			breakdown.SetFirstDay(from.Day)
+			services[sub.ServiceName] = breakdown
+		}
+		if err := total.Add(sub); err != nil {
+			return fmt.Errorf("[forecast sub %d] %w", sub.ID, err)
+		}
+		if err := breakdown.Add(sub); err != nil {
+			return fmt.Errorf("[forecast sub %d] %w", sub.ID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("[GetForecast] %w", err)
+	}
+
+	result := &subscriptions.Forecast{
+		From:     from.String(),
+		To:       to.String(),
+		Currency: filter.Currency,
+		Services: make([]subscriptions.SeriesGroup, 0, len(services)),
+	}
+	if result.Total, err = total.Total(); err != nil {
+		return nil, fmt.Errorf("[GetForecast] %w", err)
+	}
+	if result.Months, err = total.Series(); err != nil {
+		return nil, fmt.Errorf("[GetForecast] %w", err)
+	}
+	for name, breakdown := range services {
+		series, err := breakdown.Series()
+		if err != nil {
+			return nil, fmt.Errorf("[GetForecast|service %q] %w", name, err)
+		}
+		result.Services = append(result.Services, subscriptions.SeriesGroup{Group: name, Series: series})
+	}
+	sort.Slice(result.Services, func(i, j int) bool { return result.Services[i].Group < result.Services[j].Group })
+	return result, nil
+}
+
+// forEachOverlapping вызывает fn для каждой подписки, пересекающейся с периодом фильтра;
+// у подписок заданы изменения цены, чтобы каждый месяц считался по действующей в нем цене
+func forEachOverlapping(ctx context.Context, q querier, filter *subscriptions.TotalFilter, fn func(sub *subscriptions.Subscription) error) error {
+	prices, err := loadPriceChanges(ctx, q, overlappingWhere(filter), filter.AsOf)
+	if err != nil {
+		return fmt.Errorf("[forEachOverlapping] %w", err)
+	}
+
+	where := overlappingWhere(filter)
+	query := `SELECT ` + subscriptionColumns + ` FROM ` + subscriptionsFrom(where, filter.AsOf) + where.String()
+	rows, err := q.Query(ctx, query, where.args...)
+	if err != nil {
+		return fmt.Errorf("[forEachOverlapping|exec get subs] %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return fmt.Errorf("[forEachOverlapping|scan sub] %w", err)
+		}
+		if err := sub.SetPriceChanges(prices[sub.ID]); err != nil {
+			return fmt.Errorf("[forEachOverlapping|sub %d prices] %w", sub.ID, err)
+		}
+		if err := fn(sub); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("[forEachOverlapping|read rows] %w", err)
+	}
+	return nil
+}
+
+// beginSnapshot начинает транзакцию только для чтения, в которой все запросы подсчета видят один снимок БД:
+// подписки, изменения цены и курсы согласованы между собой
+func (r *PostgresRepository) beginSnapshot(ctx context.Context) (pgx.Tx, error) {
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("[beginSnapshot|begin tx] %w", err)
+	}
+	return tx, nil
+}
+
+// overlappingWhere отбирает подписки фильтра, пересекающиеся с его периодом
+func overlappingWhere(filter *subscriptions.TotalFilter) *whereBuilder {
+	period := filter.Period()
+	where := totalFilterWhere(filter)
+	where.add("start_date <= %s", period.End.Time())
+	where.add("(end_date IS NULL OR end_date >= %s)", period.Start.Time())
+	return where
+}
+
+// scopedTotalFilter возвращает копию фильтра, ограниченную подписками, доступными клиенту
+func scopedTotalFilter(ctx context.Context, filter *subscriptions.TotalFilter) (*subscriptions.TotalFilter, error) {
+	scope, err := scopeFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scoped := *filter
+	if scoped.UserID, err = scope.filterUser(filter.UserID); err != nil {
+		return nil, err
+	}
+	return &scoped, nil
+}
+
+// totalFilterWhere добавляет фильтрацию по user_id и сервису, если они заданы; удаленные подписки не учитываются
+func totalFilterWhere(filter *subscriptions.TotalFilter) *whereBuilder {
+	where := &whereBuilder{}
+	where.addRaw("deleted_at IS NULL")
+	if filter.UserID != uuid.Nil {
+		where.add("user_id = %s", filter.UserID)
+	}
+	if filter.ServiceName != "" {
+		addServiceFilter(where, filter.ServiceName)
+	}
+	return where
+}
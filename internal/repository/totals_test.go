@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/subscriptions_api/subscriptions"
+)
+
+func TestTotalPriceChanges(t *testing.T) {
+	pool, m := testMigrations(t)
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatalf("migrate up: %v", err)
+	}
+	repo := NewPostgresRepository(pool, Timeouts{})
+	ctx := context.Background()
+
+	// цену можно изменить только с будущего месяца
+	start := subscriptions.MonthOf(time.Now()).AddMonths(1)
+	end := start.AddMonths(2).String()
+	sub := &subscriptions.Subscription{ServiceName: "Yandex Plus", Price: 10000, UserID: uuid.Must(uuid.NewV4()), StartDate: start.String(), EndDate: &end}
+	if err := subscriptions.Validate(sub); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CreateSubscription(ctx, sub); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := repo.SchedulePriceChange(ctx, sub.ID, &subscriptions.PriceChange{Month: start.AddMonths(1).String(), Price: 20000}); err != nil {
+		t.Fatalf("schedule price change: %v", err)
+	}
+
+	tests := []struct {
+		mode string
+		want subscriptions.Amount
+	}{
+		// в режиме contained подписка учитывается один раз по цене месяца начала
+		{subscriptions.TotalModeContained, 10000},
+		{subscriptions.TotalModeOverlap, 10000 + 2*20000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			filter := &subscriptions.TotalFilter{StartDate: start.String(), EndDate: end, UserID: sub.UserID, Mode: tt.mode}
+			if err := subscriptions.ValidateTotalFilter(filter); err != nil {
+				t.Fatal(err)
+			}
+			got, err := repo.GetTotalPriceInPeriod(ctx, filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("total = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
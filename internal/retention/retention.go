@@ -0,0 +1,42 @@
+package retention
+
+import (
+	"context"
+	"time"
+
+	"github.com/subscriptions_api/internal/logger"
+)
+
+// Purger - хранилище, из которого окончательно удаляются подписки, помеченные удаленными
+type Purger interface {
+	PurgeDeletedSubscriptions(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Run раз в interval удаляет подписки, помеченные удаленными раньше, чем retention назад.
+// Первая очистка выполняется сразу; Run блокируется до отмены ctx
+func Run(ctx context.Context, p Purger, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purge(ctx, p, retention)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func purge(ctx context.Context, p Purger, retention time.Duration) {
+	before := time.Now().Add(-retention)
+	purged, err := p.PurgeDeletedSubscriptions(ctx, before)
+	if err != nil {
+		logger.L.Error("failed purge deleted subscriptions", "purged", purged, "error", err)
+		return
+	}
+	if purged > 0 {
+		logger.L.Info("purged deleted subscriptions", "purged", purged, "deleted_before", before)
+	}
+}
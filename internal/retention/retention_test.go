@@ -0,0 +1,73 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/subscriptions_api/internal/logger"
+)
+
+// chanPurger передает момент каждой очистки в канал
+type chanPurger struct {
+	calls chan time.Time
+	err   error
+}
+
+func (p *chanPurger) PurgeDeletedSubscriptions(_ context.Context, before time.Time) (int64, error) {
+	p.calls <- before
+	return 1, p.err
+}
+
+func TestRun(t *testing.T) {
+	logger.L = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "purged"},
+		// ошибка очистки не останавливает задачу
+		{name: "purge error", err: errors.New("connection refused")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const retention = 30 * 24 * time.Hour
+			p := &chanPurger{calls: make(chan time.Time), err: tt.err}
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			started := time.Now()
+			go func() {
+				Run(ctx, p, retention, time.Millisecond)
+				close(done)
+			}()
+
+			// первая очистка выполняется сразу, следующие - по таймеру
+			for i := 0; i < 3; i++ {
+				select {
+				case before := <-p.calls:
+					if before.After(time.Now().Add(-retention)) || before.Before(started.Add(-retention)) {
+						t.Errorf("purge %d before = %v, want about %v", i, before, started.Add(-retention))
+					}
+				case <-time.After(time.Second):
+					t.Fatalf("purge %d was not called", i)
+				}
+			}
+
+			cancel()
+			// после отмены задача может успеть начать еще одну очистку
+			for stopped := false; !stopped; {
+				select {
+				case <-p.calls:
+				case <-done:
+					stopped = true
+				case <-time.After(time.Second):
+					t.Fatal("Run did not stop after cancel")
+				}
+			}
+		})
+	}
+}
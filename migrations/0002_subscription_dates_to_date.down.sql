@@ -0,0 +1,20 @@
+DROP INDEX IF EXISTS subscriptions_service_name_start_date_idx;
+DROP INDEX IF EXISTS subscriptions_user_id_start_date_idx;
+
+ALTER TABLE subscriptions
+	DROP CONSTRAINT IF EXISTS subscriptions_start_date_check,
+	DROP CONSTRAINT IF EXISTS subscriptions_end_date_check;
+
+ALTER TABLE subscriptions
+	ALTER COLUMN start_date TYPE VARCHAR(10) USING TO_CHAR(start_date, 'MM-YYYY'),
+	ALTER COLUMN end_date TYPE VARCHAR(10) USING TO_CHAR(end_date, 'MM-YYYY');
+
+ALTER TABLE subscriptions
+	ADD CONSTRAINT subscriptions_start_date_check CHECK (
+		start_date ~ '^(0[1-9]|1[0-2])-[2-9][0-9]{3}$'
+		AND TO_DATE(start_date, 'MM-YYYY') IS NOT NULL
+	),
+	ADD CONSTRAINT subscriptions_end_date_check CHECK (
+		end_date ~ '^(0[1-9]|1[0-2])-[2-9][0-9]{3}$'
+		AND TO_DATE(end_date, 'MM-YYYY') IS NOT NULL
+	);
@@ -0,0 +1,21 @@
+-- переводим даты из VARCHAR MM-YYYY в DATE (первое число месяца)
+ALTER TABLE subscriptions
+	DROP CONSTRAINT IF EXISTS subscriptions_start_date_check,
+	DROP CONSTRAINT IF EXISTS subscriptions_end_date_check;
+
+ALTER TABLE subscriptions
+	ALTER COLUMN start_date TYPE DATE USING TO_DATE('01-' || start_date, 'DD-MM-YYYY'),
+	ALTER COLUMN end_date TYPE DATE USING TO_DATE('01-' || end_date, 'DD-MM-YYYY');
+
+ALTER TABLE subscriptions
+	ADD CONSTRAINT subscriptions_start_date_check CHECK (
+		start_date = date_trunc('month', start_date)::date
+		AND start_date >= DATE '2000-01-01'
+	),
+	ADD CONSTRAINT subscriptions_end_date_check CHECK (
+		end_date = date_trunc('month', end_date)::date
+		AND end_date >= start_date
+	);
+
+CREATE INDEX IF NOT EXISTS subscriptions_user_id_start_date_idx ON subscriptions (user_id, start_date);
+CREATE INDEX IF NOT EXISTS subscriptions_service_name_start_date_idx ON subscriptions (service_name, start_date);
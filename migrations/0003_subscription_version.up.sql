@@ -0,0 +1,3 @@
+-- версия записи для оптимистичной блокировки (ETag / If-Match)
+ALTER TABLE subscriptions
+	ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1 CHECK (version > 0);
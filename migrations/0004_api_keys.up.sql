@@ -0,0 +1,11 @@
+-- API ключи клиентов; хранится только SHA-256 хэш ключа
+CREATE TABLE IF NOT EXISTS api_keys
+(
+	api_key_id SERIAL PRIMARY KEY,
+	name VARCHAR(64) NOT NULL,
+	key_hash CHAR(64) NOT NULL UNIQUE,
+	-- пользователь, от имени которого действует ключ; NULL - ключ не привязан к пользователю
+	user_id UUID,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	revoked_at TIMESTAMPTZ
+);
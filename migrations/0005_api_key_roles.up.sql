@@ -0,0 +1,3 @@
+-- роль клиента, действующего по ключу: user видит только подписки своего user_id, admin - все
+ALTER TABLE api_keys
+	ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin'));
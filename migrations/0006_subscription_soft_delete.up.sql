@@ -0,0 +1,6 @@
+-- мягкое удаление: удаленная запись помечается временем удаления и исключается из выборок
+ALTER TABLE subscriptions
+	ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- для очистки удаленных записей по сроку хранения
+CREATE INDEX IF NOT EXISTS subscriptions_deleted_at_idx ON subscriptions (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS subscriptions_audit ON subscriptions;
+DROP FUNCTION IF EXISTS subscription_events_log();
+DROP TABLE IF EXISTS subscription_events;
+DROP FUNCTION IF EXISTS subscription_events_append_only();
@@ -0,0 +1,67 @@
+-- журнал изменений подписок; пишется триггером в той же транзакции, что и изменение
+CREATE TABLE IF NOT EXISTS subscription_events
+(
+	event_id BIGSERIAL PRIMARY KEY,
+	-- без внешнего ключа: события остаются после очистки удаленной подписки
+	subscription_id INTEGER NOT NULL,
+	operation VARCHAR(16) NOT NULL CHECK (operation IN ('create', 'update', 'delete', 'restore', 'purge')),
+	actor VARCHAR(256) NOT NULL,
+	occurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	before JSONB,
+	after JSONB
+);
+
+CREATE INDEX IF NOT EXISTS subscription_events_subscription_id_idx ON subscription_events (subscription_id, event_id);
+
+-- автор изменения передается репозиторием через set_config('subscriptions.actor', ..., true);
+-- изменения в обход приложения записываются от имени пользователя БД
+CREATE OR REPLACE FUNCTION subscription_events_log() RETURNS trigger AS $$
+DECLARE
+	op TEXT;
+	sub_id INTEGER;
+	before_row JSONB;
+	after_row JSONB;
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		op := 'create';
+		sub_id := NEW.subscription_id;
+		after_row := to_jsonb(NEW);
+	ELSIF TG_OP = 'DELETE' THEN
+		op := 'purge';
+		sub_id := OLD.subscription_id;
+		before_row := to_jsonb(OLD);
+	ELSE
+		IF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+			op := 'delete';
+		ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+			op := 'restore';
+		ELSE
+			op := 'update';
+		END IF;
+		sub_id := NEW.subscription_id;
+		before_row := to_jsonb(OLD);
+		after_row := to_jsonb(NEW);
+	END IF;
+
+	INSERT INTO subscription_events (subscription_id, operation, actor, before, after)
+	VALUES (sub_id, op, COALESCE(NULLIF(current_setting('subscriptions.actor', true), ''), current_user), before_row, after_row);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS subscriptions_audit ON subscriptions;
+CREATE TRIGGER subscriptions_audit
+	AFTER INSERT OR UPDATE OR DELETE ON subscriptions
+	FOR EACH ROW EXECUTE FUNCTION subscription_events_log();
+
+-- журнал только дополняется
+CREATE OR REPLACE FUNCTION subscription_events_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'subscription_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS subscription_events_append_only ON subscription_events;
+CREATE TRIGGER subscription_events_append_only
+	BEFORE UPDATE OR DELETE ON subscription_events
+	FOR EACH ROW EXECUTE FUNCTION subscription_events_append_only();
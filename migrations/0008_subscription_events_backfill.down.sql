@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS subscription_events_occurred_at_idx;
+ALTER TABLE subscription_events DISABLE TRIGGER subscription_events_append_only;
+DELETE FROM subscription_events WHERE actor = 'backfill';
+ALTER TABLE subscription_events ENABLE TRIGGER subscription_events_append_only;
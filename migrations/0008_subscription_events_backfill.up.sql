@@ -0,0 +1,21 @@
+-- события создания для подписок, появившихся до журнала: состояние до первого изменения или текущее
+INSERT INTO subscription_events (subscription_id, operation, actor, occurred_at, before, after)
+SELECT s.subscription_id, 'create', 'backfill',
+	COALESCE(first_event.occurred_at - interval '1 microsecond', now()),
+	NULL,
+	COALESCE(first_event.before, to_jsonb(s))
+FROM subscriptions s
+LEFT JOIN LATERAL (
+	SELECT e.occurred_at, e.before
+	FROM subscription_events e
+	WHERE e.subscription_id = s.subscription_id
+	ORDER BY e.occurred_at, e.event_id
+	LIMIT 1
+) first_event ON true
+WHERE NOT EXISTS (
+	SELECT 1 FROM subscription_events e
+	WHERE e.subscription_id = s.subscription_id AND e.operation = 'create'
+);
+
+-- восстановление состояния ищет последнее событие каждой подписки на момент as_of
+CREATE INDEX IF NOT EXISTS subscription_events_occurred_at_idx ON subscription_events (subscription_id, occurred_at DESC, event_id DESC);
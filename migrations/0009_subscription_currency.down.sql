@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS currency_rates;
+DROP FUNCTION IF EXISTS currency_rates_append_only();
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS currency;
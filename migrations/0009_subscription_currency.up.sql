@@ -0,0 +1,26 @@
+-- валюта цены подписки (ISO 4217); существующие подписки считаются рублевыми
+ALTER TABLE subscriptions
+	ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'RUB'
+	CONSTRAINT subscriptions_currency_check CHECK (currency ~ '^[A-Z]{3}$');
+
+-- курсы валют к рублю с месяца effective_from; исправление курса - новая версия с более поздним created_at
+CREATE TABLE IF NOT EXISTS currency_rates (
+	rate_id BIGSERIAL PRIMARY KEY,
+	currency CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$' AND currency <> 'RUB'),
+	effective_from DATE NOT NULL CHECK (effective_from = date_trunc('month', effective_from)::date),
+	rate NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS currency_rates_version_idx ON currency_rates (currency, effective_from, created_at DESC, rate_id DESC);
+
+CREATE OR REPLACE FUNCTION currency_rates_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'currency_rates is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS currency_rates_append_only ON currency_rates;
+CREATE TRIGGER currency_rates_append_only
+	BEFORE UPDATE OR DELETE ON currency_rates
+	FOR EACH ROW EXECUTE FUNCTION currency_rates_append_only();
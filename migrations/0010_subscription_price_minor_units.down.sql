@@ -0,0 +1,6 @@
+DROP FUNCTION IF EXISTS subscription_event_state(JSONB, VARCHAR);
+ALTER TABLE subscription_events DROP COLUMN IF EXISTS price_unit;
+
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_price_check;
+ALTER TABLE subscriptions
+	ALTER COLUMN price TYPE INTEGER USING (price / 100)::integer;
@@ -0,0 +1,18 @@
+-- цена хранится в минорных единицах валюты (копейках, центах); существующие цены были целыми единицами
+ALTER TABLE subscriptions
+	ALTER COLUMN price TYPE BIGINT USING price::bigint * 100;
+
+ALTER TABLE subscriptions
+	ADD CONSTRAINT subscriptions_price_check CHECK (price >= 0);
+
+-- единицы цены в состояниях журнала: прежние события записаны в целых единицах
+ALTER TABLE subscription_events
+	ADD COLUMN IF NOT EXISTS price_unit VARCHAR(8) NOT NULL DEFAULT 'major' CHECK (price_unit IN ('major', 'minor'));
+
+ALTER TABLE subscription_events ALTER COLUMN price_unit SET DEFAULT 'minor';
+
+-- состояние подписки из журнала с ценой в минорных единицах
+CREATE OR REPLACE FUNCTION subscription_event_state(state JSONB, price_unit VARCHAR) RETURNS JSONB AS $$
+	SELECT CASE WHEN price_unit = 'major' AND state ? 'price'
+		THEN jsonb_set(state, '{price}', to_jsonb((state ->> 'price')::bigint * 100)) ELSE state END
+$$ LANGUAGE sql IMMUTABLE;
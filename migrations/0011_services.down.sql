@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS subscriptions_resolve_service ON subscriptions;
+DROP FUNCTION IF EXISTS subscriptions_resolve_service();
+DROP INDEX IF EXISTS subscriptions_service_id_start_date_idx;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS service_id;
+DROP TABLE IF EXISTS service_aliases;
+DROP TABLE IF EXISTS services;
+DROP FUNCTION IF EXISTS service_key(TEXT);
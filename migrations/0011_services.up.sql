@@ -0,0 +1,115 @@
+-- ключ для сравнения названий сервисов: без учета регистра и лишних пробелов
+CREATE OR REPLACE FUNCTION service_key(name TEXT) RETURNS TEXT AS $$
+	SELECT lower(regexp_replace(btrim(name), '\s+', ' ', 'g'))
+$$ LANGUAGE sql IMMUTABLE;
+
+-- справочник сервисов с каноническим названием и категорией
+CREATE TABLE IF NOT EXISTS services
+(
+	service_id SERIAL PRIMARY KEY,
+	name VARCHAR(32) NOT NULL,
+	category VARCHAR(64),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- названия сервиса, включая каноническое; одно название принадлежит одному сервису
+CREATE TABLE IF NOT EXISTS service_aliases
+(
+	alias_key VARCHAR(32) PRIMARY KEY,
+	service_id INTEGER NOT NULL REFERENCES services (service_id) ON DELETE CASCADE,
+	alias VARCHAR(32) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS service_aliases_service_id_idx ON service_aliases (service_id);
+
+-- названия с одинаковым ключом - один сервис с самым частым написанием
+WITH names AS (
+	SELECT service_key(service_name) AS key, regexp_replace(btrim(service_name), '\s+', ' ', 'g') AS name, count(*) AS n
+	FROM subscriptions
+	GROUP BY 1, 2
+), canonical AS (
+	SELECT DISTINCT ON (key) key, name
+	FROM names
+	ORDER BY key, n DESC, name
+), inserted AS (
+	INSERT INTO services (name)
+	SELECT name FROM canonical ORDER BY key
+	RETURNING service_id, name
+)
+INSERT INTO service_aliases (alias_key, service_id, alias)
+SELECT service_key(name), service_id, name FROM inserted;
+
+ALTER TABLE subscriptions
+	ADD COLUMN IF NOT EXISTS service_id INTEGER REFERENCES services (service_id);
+
+-- ссылка на сервис не меняет подписку и в журнал не пишется
+ALTER TABLE subscriptions DISABLE TRIGGER subscriptions_audit;
+
+UPDATE subscriptions s SET service_id = a.service_id
+FROM service_aliases a
+WHERE a.alias_key = service_key(s.service_name);
+
+ALTER TABLE subscriptions ENABLE TRIGGER subscriptions_audit;
+
+-- замена названия каноническим пишется в журнал от имени migration
+SELECT set_config('subscriptions.actor', 'migration', true);
+
+UPDATE subscriptions s SET
+	service_name = sv.name,
+	version = s.version + 1
+FROM services sv
+WHERE sv.service_id = s.service_id AND s.service_name <> sv.name;
+
+ALTER TABLE subscriptions
+	ALTER COLUMN service_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS subscriptions_service_id_start_date_idx ON subscriptions (service_id, start_date);
+
+-- название сервиса заменяется каноническим; service_id важнее названия, новое название - новый сервис
+CREATE OR REPLACE FUNCTION subscriptions_resolve_service() RETURNS trigger AS $$
+DECLARE
+	key TEXT;
+	found_id INTEGER;
+	found_name VARCHAR(32);
+BEGIN
+	-- запись без service_id сохраняет прежний сервис, если не изменилось название
+	IF TG_OP = 'UPDATE' AND NEW.service_id IS NULL THEN
+		NEW.service_id := OLD.service_id;
+	END IF;
+	IF NEW.service_id IS NOT NULL AND (TG_OP = 'INSERT' OR NEW.service_id IS DISTINCT FROM OLD.service_id OR btrim(NEW.service_name) = '') THEN
+		SELECT name INTO found_name FROM services WHERE service_id = NEW.service_id;
+		IF NOT FOUND THEN
+			RAISE EXCEPTION 'service % does not exist', NEW.service_id
+				USING ERRCODE = 'foreign_key_violation', COLUMN = 'service_id';
+		END IF;
+		NEW.service_name := found_name;
+		RETURN NEW;
+	END IF;
+	IF TG_OP = 'UPDATE' AND NEW.service_name IS NOT DISTINCT FROM OLD.service_name THEN
+		RETURN NEW;
+	END IF;
+
+	key := service_key(NEW.service_name);
+	-- параллельная регистрация одного и того же названия выполняется по очереди
+	PERFORM pg_advisory_xact_lock(hashtext('service:' || key));
+
+	SELECT a.service_id, s.name INTO found_id, found_name
+	FROM service_aliases a
+	JOIN services s USING (service_id)
+	WHERE a.alias_key = key;
+	IF NOT FOUND THEN
+		INSERT INTO services (name) VALUES (regexp_replace(btrim(NEW.service_name), '\s+', ' ', 'g'))
+		RETURNING service_id, name INTO found_id, found_name;
+		INSERT INTO service_aliases (alias_key, service_id, alias) VALUES (key, found_id, found_name);
+	END IF;
+
+	NEW.service_id := found_id;
+	NEW.service_name := found_name;
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS subscriptions_resolve_service ON subscriptions;
+CREATE TRIGGER subscriptions_resolve_service
+	BEFORE INSERT OR UPDATE ON subscriptions
+	FOR EACH ROW EXECUTE FUNCTION subscriptions_resolve_service();
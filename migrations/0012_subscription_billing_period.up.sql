@@ -0,0 +1,12 @@
+-- период списания: price - сумма одного списания; существующие подписки ежемесячные
+ALTER TABLE subscriptions
+	ADD COLUMN IF NOT EXISTS billing_period VARCHAR(16) NOT NULL DEFAULT 'monthly'
+		CONSTRAINT subscriptions_billing_period_check
+		CHECK (billing_period IN ('weekly', 'monthly', 'quarterly', 'yearly', 'custom')),
+	ADD COLUMN IF NOT EXISTS billing_months SMALLINT
+		CONSTRAINT subscriptions_billing_months_check CHECK (billing_months BETWEEN 1 AND 120);
+
+-- число месяцев между списаниями задается только для custom
+ALTER TABLE subscriptions
+	ADD CONSTRAINT subscriptions_billing_custom_check
+	CHECK ((billing_period = 'custom') = (billing_months IS NOT NULL));
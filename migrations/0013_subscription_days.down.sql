@@ -0,0 +1,4 @@
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_days_order_check;
+ALTER TABLE subscriptions
+	DROP COLUMN IF EXISTS end_day,
+	DROP COLUMN IF EXISTS start_day;
@@ -0,0 +1,14 @@
+-- даты с точностью до дня: start_date и end_date остаются первыми числами месяцев,
+-- день начала и окончания хранится отдельно; NULL - дата с точностью до месяца (MM-YYYY)
+ALTER TABLE subscriptions
+	ADD COLUMN IF NOT EXISTS start_day SMALLINT
+		CONSTRAINT subscriptions_start_day_check
+		CHECK (start_day BETWEEN 1 AND extract(day FROM start_date + interval '1 month - 1 day')),
+	ADD COLUMN IF NOT EXISTS end_day SMALLINT
+		CONSTRAINT subscriptions_end_day_check
+		CHECK (end_day IS NULL OR (end_date IS NOT NULL AND end_day BETWEEN 1 AND extract(day FROM end_date + interval '1 month - 1 day')));
+
+-- в одном месяце день окончания не раньше дня начала
+ALTER TABLE subscriptions
+	ADD CONSTRAINT subscriptions_days_order_check
+	CHECK (end_date IS NULL OR end_date > start_date OR COALESCE(end_day, 31) >= COALESCE(start_day, 1));
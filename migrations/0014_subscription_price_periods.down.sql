@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS subscription_price_periods;
+DROP FUNCTION IF EXISTS subscription_price_events_log();
+DROP FUNCTION IF EXISTS subscription_price_periods_append_only();
+-- события price остаются в журнале, ограничение проверяет только новые строки
+ALTER TABLE subscription_events DROP CONSTRAINT IF EXISTS subscription_events_operation_check;
+ALTER TABLE subscription_events
+	ADD CONSTRAINT subscription_events_operation_check
+	CHECK (operation IN ('create', 'update', 'delete', 'restore', 'purge')) NOT VALID;
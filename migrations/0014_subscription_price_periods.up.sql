@@ -0,0 +1,49 @@
+-- изменения цены с месяца effective_from; замененные и отмененные строки получают superseded_at, без внешнего ключа
+CREATE TABLE IF NOT EXISTS subscription_price_periods (
+	price_period_id BIGSERIAL PRIMARY KEY,
+	subscription_id INTEGER NOT NULL,
+	effective_from DATE NOT NULL CHECK (effective_from = date_trunc('month', effective_from)::date),
+	price BIGINT NOT NULL CHECK (price >= 0),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	superseded_at TIMESTAMPTZ CHECK (superseded_at >= created_at)
+);
+
+-- на каждый месяц действует одна цена
+CREATE UNIQUE INDEX IF NOT EXISTS subscription_price_periods_current_idx
+	ON subscription_price_periods (subscription_id, effective_from) WHERE superseded_at IS NULL;
+
+-- строку можно только один раз пометить замененной
+CREATE OR REPLACE FUNCTION subscription_price_periods_append_only() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'UPDATE' AND OLD.superseded_at IS NULL AND NEW.superseded_at IS NOT NULL
+		AND to_jsonb(NEW) - 'superseded_at' = to_jsonb(OLD) - 'superseded_at' THEN
+		RETURN NEW;
+	END IF;
+	RAISE EXCEPTION 'subscription_price_periods is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS subscription_price_periods_append_only ON subscription_price_periods;
+CREATE TRIGGER subscription_price_periods_append_only
+	BEFORE UPDATE OR DELETE ON subscription_price_periods
+	FOR EACH ROW EXECUTE FUNCTION subscription_price_periods_append_only();
+
+-- изменения цены пишутся в журнал подписки операцией price
+ALTER TABLE subscription_events DROP CONSTRAINT IF EXISTS subscription_events_operation_check;
+ALTER TABLE subscription_events
+	ADD CONSTRAINT subscription_events_operation_check
+	CHECK (operation IN ('create', 'update', 'delete', 'restore', 'purge', 'price'));
+
+CREATE OR REPLACE FUNCTION subscription_price_events_log() RETURNS trigger AS $$
+BEGIN
+	INSERT INTO subscription_events (subscription_id, operation, actor, before, after)
+	VALUES (NEW.subscription_id, 'price', COALESCE(NULLIF(current_setting('subscriptions.actor', true), ''), current_user),
+		CASE WHEN TG_OP = 'UPDATE' THEN to_jsonb(OLD) END, to_jsonb(NEW));
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS subscription_price_periods_audit ON subscription_price_periods;
+CREATE TRIGGER subscription_price_periods_audit
+	AFTER INSERT OR UPDATE ON subscription_price_periods
+	FOR EACH ROW EXECUTE FUNCTION subscription_price_events_log();
@@ -0,0 +1,10 @@
+-- начало журнала: as_of раньше started_at отклоняется, без подписок из 0008 строки нет
+CREATE TABLE IF NOT EXISTS subscription_journal (
+	id BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+	started_at TIMESTAMPTZ NOT NULL
+);
+
+INSERT INTO subscription_journal (started_at)
+SELECT max(occurred_at) FROM subscription_events WHERE actor = 'backfill'
+HAVING count(*) > 0
+ON CONFLICT (id) DO NOTHING;
@@ -7,13 +7,37 @@ import (
 	"github.com/subscriptions_api/handlers"
 )
 
-func InitRoutes(app *fiber.App) {
-	api := app.Group("/api")
-	api.Post("/subscriptions", handlers.CreateSubscription)
-	api.Get("/subscriptions/:id", handlers.GetSubscription)
-	api.Put("/subscriptions/:id", handlers.UpdateSubscription)
-	api.Delete("/subscriptions/:id", handlers.DeleteSubscription)
-	api.Get("/subscriptions", handlers.GetAllSubscriptions)
-	api.Get("/total", handlers.GetTotalPriceInPeriod)
+// InitRoutes регистрирует маршруты API; middleware (например, аутентификация) применяются ко всем маршрутам /api.
+// Тело запроса ограничено bodyLimit байт везде, кроме импорта, который читает файл потоком
+func InitRoutes(app *fiber.App, h *handlers.Handler, bodyLimit int, middleware ...fiber.Handler) {
+	api := app.Group("/api", middleware...)
+	// импорт регистрируется до LimitBody: fiber выполняет обработчики в порядке регистрации
+	api.Post("/subscriptions/import", h.ImportSubscriptions)
+	api.Use(handlers.LimitBody(bodyLimit))
+	api.Post("/subscriptions", h.CreateSubscription)
+	api.Post("/subscriptions/batch", h.ApplyBatch)
+	api.Get("/subscriptions/export", h.ExportSubscriptions)
+	api.Get("/subscriptions/:id", h.GetSubscription)
+	api.Put("/subscriptions/:id", h.UpdateSubscription)
+	api.Patch("/subscriptions/:id", h.PatchSubscription)
+	api.Delete("/subscriptions/:id", h.DeleteSubscription)
+	api.Post("/subscriptions/:id/restore", h.RestoreSubscription)
+	api.Get("/subscriptions/:id/history", h.GetSubscriptionHistory)
+	api.Get("/subscriptions/:id/prices", h.GetPriceChanges)
+	api.Post("/subscriptions/:id/prices", h.SchedulePriceChange)
+	api.Delete("/subscriptions/:id/prices/:month", h.CancelPriceChange)
+	api.Get("/subscriptions", h.GetAllSubscriptions)
+	api.Get("/total", h.GetTotalPriceInPeriod)
+	api.Get("/total/breakdown", h.GetMonthlyBreakdown)
+	api.Get("/total/breakdown/export", h.ExportMonthlyBreakdown)
+	api.Get("/forecast", h.GetForecast)
+	api.Get("/rates", h.GetRates)
+	api.Put("/rates", h.UpsertRates)
+	api.Get("/services", h.GetAllServices)
+	api.Post("/services", h.CreateService)
+	api.Get("/services/:id", h.GetService)
+	api.Put("/services/:id", h.UpdateService)
+	api.Delete("/services/:id", h.DeleteService)
+	api.Post("/services/:id/merge", h.MergeServices)
 	app.Get("/swagger/*", swagger.HandlerDefault)
 }
@@ -0,0 +1,89 @@
+package subscriptions
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxBatchItems ограничивает число операций в одном пакетном запросе
+const MaxBatchItems = 1000
+
+// операции пакетного запроса
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+// режимы выполнения пакетного запроса
+const (
+	// BatchModeAtomic - все операции применяются в одной транзакции, любая ошибка отменяет весь пакет
+	BatchModeAtomic = "all-or-nothing"
+	// BatchModeBestEffort - успешные операции применяются независимо от ошибок в остальных
+	BatchModeBestEffort = "best-effort"
+)
+
+var (
+	ErrWrongBatchMode      = errors.New("wrong batch mode")
+	ErrWrongBatchSize      = errors.New("wrong number of batch items")
+	ErrWrongBatchOp        = errors.New("wrong batch operation")
+	ErrWrongID             = errors.New("wrong subscription id")
+	ErrWrongVersion        = errors.New("wrong subscription version")
+	ErrMissingSubscription = errors.New("subscription is required")
+)
+
+// BatchRequest описывает пакет операций над подписками
+// @Description Пакет операций над подписками
+type BatchRequest struct {
+	Mode  string      `json:"mode" enums:"all-or-nothing,best-effort" example:"best-effort"`
+	Items []BatchItem `json:"items"`
+}
+
+// BatchItem описывает одну операцию пакета
+// @Description Операция пакетного запроса
+type BatchItem struct {
+	Op           string        `json:"op" enums:"create,update,delete" example:"create"`
+	ID           int           `json:"subscription_id,omitempty"`
+	Version      int           `json:"version,omitempty"`
+	Subscription *Subscription `json:"subscription,omitempty"`
+}
+
+// ValidateBatch проверяет режим и размер пакета и проставляет режим по умолчанию
+func ValidateBatch(req *BatchRequest) error {
+	switch req.Mode {
+	case "":
+		req.Mode = BatchModeAtomic
+	case BatchModeAtomic, BatchModeBestEffort:
+	default:
+		return fmt.Errorf("[ValidateBatch|mode] %w", fieldError("mode", ErrWrongBatchMode))
+	}
+
+	if len(req.Items) == 0 || len(req.Items) > MaxBatchItems {
+		return fmt.Errorf("[ValidateBatch|items] %w", fieldError("items", ErrWrongBatchSize))
+	}
+	return nil
+}
+
+// ValidateBatchItem проверяет одну операцию пакета
+func ValidateBatchItem(item *BatchItem) error {
+	switch item.Op {
+	case BatchOpCreate, BatchOpUpdate, BatchOpDelete:
+	default:
+		return fmt.Errorf("[ValidateBatchItem|op] %w", fieldError("op", ErrWrongBatchOp))
+	}
+
+	if item.Op != BatchOpCreate && item.ID <= 0 {
+		return fmt.Errorf("[ValidateBatchItem|id] %w", fieldError("subscription_id", ErrWrongID))
+	}
+	if item.Version < 0 {
+		return fmt.Errorf("[ValidateBatchItem|version] %w", fieldError("version", ErrWrongVersion))
+	}
+
+	if item.Op == BatchOpDelete {
+		return nil
+	}
+	if item.Subscription == nil {
+		return fmt.Errorf("[ValidateBatchItem|subscription] %w", fieldError("subscription", ErrMissingSubscription))
+	}
+	return Validate(item.Subscription)
+}
@@ -0,0 +1,60 @@
+package subscriptions
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateBatch(t *testing.T) {
+	tests := []struct {
+		name string
+		req  BatchRequest
+		mode string
+		err  error
+	}{
+		{name: "default mode", req: BatchRequest{Items: make([]BatchItem, 1)}, mode: BatchModeAtomic},
+		{name: "best effort", req: BatchRequest{Mode: BatchModeBestEffort, Items: make([]BatchItem, MaxBatchItems)}, mode: BatchModeBestEffort},
+		{name: "wrong mode", req: BatchRequest{Mode: "all", Items: make([]BatchItem, 1)}, err: ErrWrongBatchMode},
+		{name: "empty", req: BatchRequest{}, err: ErrWrongBatchSize},
+		{name: "too many", req: BatchRequest{Items: make([]BatchItem, MaxBatchItems+1)}, err: ErrWrongBatchSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			if err := ValidateBatch(&req); !errors.Is(err, tt.err) {
+				t.Fatalf("ValidateBatch error = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && req.Mode != tt.mode {
+				t.Errorf("Mode = %q, want %q", req.Mode, tt.mode)
+			}
+		})
+	}
+}
+
+func TestValidateBatchItem(t *testing.T) {
+	sub := func() *Subscription {
+		return &Subscription{ServiceName: "Yandex Plus", Price: 39900, StartDate: "01-2025"}
+	}
+	tests := []struct {
+		name string
+		item BatchItem
+		err  error
+	}{
+		{name: "create", item: BatchItem{Op: BatchOpCreate, Subscription: sub()}},
+		{name: "update", item: BatchItem{Op: BatchOpUpdate, ID: 1, Version: 2, Subscription: sub()}},
+		{name: "delete", item: BatchItem{Op: BatchOpDelete, ID: 1}},
+		{name: "wrong op", item: BatchItem{Op: "upsert", Subscription: sub()}, err: ErrWrongBatchOp},
+		{name: "update without id", item: BatchItem{Op: BatchOpUpdate, Subscription: sub()}, err: ErrWrongID},
+		{name: "delete without id", item: BatchItem{Op: BatchOpDelete}, err: ErrWrongID},
+		{name: "negative version", item: BatchItem{Op: BatchOpDelete, ID: 1, Version: -1}, err: ErrWrongVersion},
+		{name: "create without subscription", item: BatchItem{Op: BatchOpCreate}, err: ErrMissingSubscription},
+		{name: "invalid subscription", item: BatchItem{Op: BatchOpCreate, Subscription: &Subscription{ServiceName: "Yandex Plus", Price: -1, StartDate: "01-2025"}}, err: ErrWrongPrice},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateBatchItem(&tt.item); !errors.Is(err, tt.err) {
+				t.Errorf("ValidateBatchItem error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package subscriptions
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// периоды списания оплаты подписки; Price - сумма одного списания
+const (
+	BillingWeekly    = "weekly"
+	BillingMonthly   = "monthly"
+	BillingQuarterly = "quarterly"
+	BillingYearly    = "yearly"
+	// BillingCustom - списание раз в BillingMonths месяцев
+	BillingCustom = "custom"
+)
+
+// MaxBillingMonths ограничивает период списания BillingCustom
+const MaxBillingMonths = 120
+
+var (
+	ErrWrongBillingPeriod = errors.New("wrong billing period")
+	ErrWrongBillingMonths = errors.New("wrong billing months")
+)
+
+// ValidateBilling проверяет период списания и проставляет monthly по умолчанию
+func ValidateBilling(sub *Subscription) error {
+	switch sub.BillingPeriod {
+	case "":
+		sub.BillingPeriod = BillingMonthly
+	case BillingWeekly, BillingMonthly, BillingQuarterly, BillingYearly, BillingCustom:
+	default:
+		return fmt.Errorf("[ValidateBilling|billing_period] %w", fieldError("billing_period", ErrWrongBillingPeriod))
+	}
+
+	// число месяцев задается только для custom
+	if sub.BillingPeriod == BillingCustom {
+		if sub.BillingMonths < 1 || sub.BillingMonths > MaxBillingMonths {
+			return fmt.Errorf("[ValidateBilling|billing_months] %w", fieldError("billing_months", ErrWrongBillingMonths))
+		}
+		return nil
+	}
+	if sub.BillingMonths != 0 {
+		return fmt.Errorf("[ValidateBilling|billing_months] %w", fieldError("billing_months", ErrWrongBillingMonths))
+	}
+	return nil
+}
+
+// cycleMonths возвращает длину периода списания в месяцах; 0 - списание еженедельное
+func (s *Subscription) cycleMonths() int {
+	switch s.BillingPeriod {
+	case BillingWeekly:
+		return 0
+	case BillingQuarterly:
+		return 3
+	case BillingYearly:
+		return 12
+	case BillingCustom:
+		return s.BillingMonths
+	}
+	return 1
+}
+
+// daysIn возвращает число дней в месяце
+func daysIn(m Month) int {
+	return m.AddMonths(1).Time().AddDate(0, 0, -1).Day()
+}
+
+// ChargeBasis - способ отнесения списаний подписки к месяцам
+type ChargeBasis int
+
+const (
+	// ChargeActual - фактические списания в месяце
+	ChargeActual ChargeBasis = iota
+	// ChargeAmortized - списание, равномерно распределенное по месяцам периода (для weekly - по дням месяца)
+	ChargeAmortized
+	// ChargeProrated - распределенное списание за месяц пропорционально дням, в которые подписка активна
+	ChargeProrated
+)
+
+// chargesIn возвращает число списаний в месяце m подписки, активной с start по end.
+// Списания идут с первого дня подписки (для дат MM-YYYY - с первого числа месяца): еженедельные - каждые 7 дней,
+// остальные - в месяцы, отстоящие от начала на целое число периодов (для yearly - в месяц годовщины),
+// в тот же день месяца или в последний день, если месяц короче. Списания после окончания подписки
+// и раньше дня since не учитываются
+func (s *Subscription) chargesIn(start Date, end *Date, m Month, since int) int {
+	from, to := activeDays(start, end, m)
+	from = max(from, since)
+	if from > to {
+		return 0
+	}
+
+	cycle := s.cycleMonths()
+	if cycle > 0 {
+		day := min(start.firstDay(), daysIn(m))
+		if (MonthsBetween(start.Month, m)-1)%cycle == 0 && day >= from && day <= to {
+			return 1
+		}
+		return 0
+	}
+
+	// номера дней от первого дня подписки, на которые приходятся активные дни месяца: [from, to]
+	offset := int(m.Time().Sub(start.Month.Time())/(24*time.Hour)) - (start.firstDay() - 1)
+	from, to = offset+from-1, offset+to-1
+	// число кратных 7 на отрезке [from, to]
+	return to/7 - (from+6)/7 + 1
+}
+
+// ChargeIn возвращает сумму, приходящуюся на месяц m в минорных единицах валюты подписки, по способу basis,
+// по цене, действующей в этом месяце.
+// Месяц должен входить в активный период подписки
+func (s *Subscription) ChargeIn(m Month, basis ChargeBasis) (*big.Rat, error) {
+	return s.chargeSince(m, 1, basis)
+}
+
+// chargeSince возвращает сумму, приходящуюся на дни месяца m начиная с дня since, по способу basis
+func (s *Subscription) chargeSince(m Month, since int, basis ChargeBasis) (*big.Rat, error) {
+	start, end, err := s.ActiveDates()
+	if err != nil {
+		return nil, err
+	}
+
+	price := s.PriceIn(m).Rat()
+	if basis == ChargeActual {
+		return price.Mul(price, big.NewRat(int64(s.chargesIn(start, end, m, since)), 1)), nil
+	}
+
+	// для weekly распределенное списание за день - 1/7 цены, для остальных - 1/(cycle*daysIn) цены
+	days := daysIn(m) - (since - 1)
+	if basis == ChargeProrated {
+		from, to := activeDays(start, end, m)
+		days = max(to-max(from, since)+1, 0)
+	}
+	if cycle := s.cycleMonths(); cycle > 0 {
+		return price.Mul(price, big.NewRat(int64(days), int64(cycle*daysIn(m)))), nil
+	}
+	return price.Mul(price, big.NewRat(int64(days), 7)), nil
+}
@@ -0,0 +1,145 @@
+package subscriptions
+
+import (
+	"math/big"
+	"testing"
+)
+
+// chargeCase - сумма, приходящаяся на месяц month, в минорных единицах
+type chargeCase struct {
+	month string
+	want  *big.Rat
+}
+
+func testSubscription(t *testing.T, price Amount, period string, start string, end string) *Subscription {
+	t.Helper()
+	sub := &Subscription{ServiceName: "test", Price: price, BillingPeriod: period, StartDate: start}
+	if period == BillingCustom {
+		sub.BillingMonths = 2
+	}
+	if end != "" {
+		sub.EndDate = &end
+	}
+	if err := Validate(sub); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	return sub
+}
+
+func TestChargeIn(t *testing.T) {
+	n := func(v int64) *big.Rat { return big.NewRat(v, 1) }
+	tests := []struct {
+		name   string
+		period string
+		price  Amount
+		start  string
+		end    string
+		basis  ChargeBasis
+		cases  []chargeCase
+	}{
+		{
+			name: "weekly from first day of month", period: BillingWeekly, price: 100, start: "01-2025",
+			// 1, 8, 15, 22, 29 января; 5, 12, 19, 26 февраля и марта; 2, 9, 16, 23, 30 апреля
+			cases: []chargeCase{{"01-2025", n(500)}, {"02-2025", n(400)}, {"03-2025", n(400)}, {"04-2025", n(500)}},
+		},
+		{
+			name: "quarterly", period: BillingQuarterly, price: 300, start: "11-2024",
+			cases: []chargeCase{{"11-2024", n(300)}, {"01-2025", n(0)}, {"02-2025", n(300)}, {"05-2025", n(300)}},
+		},
+		{
+			name: "yearly", period: BillingYearly, price: 1200, start: "02-2024", end: "02-2026",
+			cases: []chargeCase{{"02-2024", n(1200)}, {"02-2025", n(1200)}, {"03-2025", n(0)}, {"02-2026", n(1200)}},
+		},
+		{
+			name: "weekly across month boundary", period: BillingWeekly, price: 100, start: "2025-01-29", end: "2025-03-05",
+			// 29 января, 5 февраля ... 26 февраля, 5 марта - последний день подписки
+			cases: []chargeCase{{"01-2025", n(100)}, {"02-2025", n(400)}, {"03-2025", n(100)}},
+		},
+		{
+			name: "weekly ends before charge", period: BillingWeekly, price: 100, start: "2025-01-29", end: "2025-03-04",
+			cases: []chargeCase{{"03-2025", n(0)}},
+		},
+		{
+			name: "monthly from day 31 into february", period: BillingMonthly, price: 100, start: "2025-01-31",
+			// в феврале списание переносится на последний день месяца
+			cases: []chargeCase{{"01-2025", n(100)}, {"02-2025", n(100)}, {"03-2025", n(100)}, {"04-2025", n(100)}},
+		},
+		{
+			name: "monthly ends before short month charge", period: BillingMonthly, price: 100, start: "2025-01-31", end: "2025-02-27",
+			cases: []chargeCase{{"02-2025", n(0)}},
+		},
+		{
+			name: "monthly ends on short month charge", period: BillingMonthly, price: 100, start: "2025-01-31", end: "2025-02-28",
+			cases: []chargeCase{{"02-2025", n(100)}},
+		},
+		{
+			name: "monthly leap february", period: BillingMonthly, price: 100, start: "2024-01-31", end: "2024-02-28",
+			// списание 29 февраля уже после окончания подписки
+			cases: []chargeCase{{"02-2024", n(0)}},
+		},
+		{
+			name: "quarterly from day 30", period: BillingQuarterly, price: 300, start: "2024-11-30",
+			cases: []chargeCase{{"11-2024", n(300)}, {"01-2025", n(0)}, {"02-2025", n(300)}, {"05-2025", n(300)}},
+		},
+		{
+			name: "yearly from leap day", period: BillingYearly, price: 1200, start: "2024-02-29",
+			cases: []chargeCase{{"02-2024", n(1200)}, {"02-2025", n(1200)}, {"03-2025", n(0)}},
+		},
+		{
+			name: "custom", period: BillingCustom, price: 200, start: "01-2025",
+			cases: []chargeCase{{"01-2025", n(200)}, {"02-2025", n(0)}, {"03-2025", n(200)}},
+		},
+		{
+			name: "amortized yearly", period: BillingYearly, price: 1200, start: "01-2025", basis: ChargeAmortized,
+			cases: []chargeCase{{"01-2025", n(100)}, {"06-2025", n(100)}},
+		},
+		{
+			name: "amortized quarterly", period: BillingQuarterly, price: 300, start: "01-2025", end: "06-2025", basis: ChargeAmortized,
+			cases: []chargeCase{{"01-2025", n(100)}, {"02-2025", n(100)}, {"06-2025", n(100)}},
+		},
+		{
+			name: "amortized weekly ignores partial month", period: BillingWeekly, price: 700, start: "2025-01-15", basis: ChargeAmortized,
+			cases: []chargeCase{{"01-2025", n(3100)}, {"02-2025", n(2800)}},
+		},
+		{
+			name: "prorated first and last months", period: BillingMonthly, price: 3100, start: "2025-01-15", end: "2025-03-10", basis: ChargeProrated,
+			// 17 из 31 дня января, весь февраль, 10 из 31 дня марта
+			cases: []chargeCase{{"01-2025", n(1700)}, {"02-2025", n(3100)}, {"03-2025", n(1000)}},
+		},
+		{
+			name: "prorated month precision", period: BillingMonthly, price: 3100, start: "01-2025", end: "02-2025", basis: ChargeProrated,
+			cases: []chargeCase{{"01-2025", n(3100)}, {"02-2025", n(3100)}},
+		},
+		{
+			name: "prorated quarterly", period: BillingQuarterly, price: 900, start: "2025-02-15", basis: ChargeProrated,
+			// 300 в месяц, 14 из 28 дней февраля
+			cases: []chargeCase{{"02-2025", n(150)}, {"03-2025", n(300)}},
+		},
+		{
+			name: "prorated weekly", period: BillingWeekly, price: 700, start: "2025-01-15", end: "2025-02-03", basis: ChargeProrated,
+			cases: []chargeCase{{"01-2025", n(1700)}, {"02-2025", n(300)}},
+		},
+		{
+			name: "prorated fraction", period: BillingMonthly, price: 100, start: "2025-01-31", basis: ChargeProrated,
+			cases: []chargeCase{{"01-2025", big.NewRat(100, 31)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := testSubscription(t, tt.price, tt.period, tt.start, tt.end)
+			for _, c := range tt.cases {
+				m, err := ParseMonth(c.month)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := sub.ChargeIn(m, tt.basis)
+				if err != nil {
+					t.Fatalf("ChargeIn(%s): %v", c.month, err)
+				}
+				if got.Cmp(c.want) != 0 {
+					t.Errorf("ChargeIn(%s) = %s, want %s", c.month, got.RatString(), c.want.RatString())
+				}
+			}
+		})
+	}
+}
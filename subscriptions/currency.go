@@ -0,0 +1,126 @@
+package subscriptions
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+)
+
+// DefaultCurrency - валюта подписок без явно указанной валюты и базовая валюта курсов
+const DefaultCurrency = "RUB"
+
+var (
+	ErrWrongCurrency = errors.New("wrong currency code")
+	ErrWrongRate     = errors.New("wrong currency rate")
+	ErrMissingRate   = errors.New("no currency rate for month")
+)
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// decimalRate - десятичная запись курса в пределах NUMERIC(20, 10), без знака и экспоненты
+var decimalRate = regexp.MustCompile(`^[0-9]{1,10}(\.[0-9]{1,10})?$`)
+
+// ValidateCurrency проверяет код валюты ISO 4217
+func ValidateCurrency(code string) error {
+	if !currencyCode.MatchString(code) {
+		return fmt.Errorf("[ValidateCurrency] %w", ErrWrongCurrency)
+	}
+	return nil
+}
+
+// Rate - курс валюты: стоимость одной единицы валюты в DefaultCurrency, действующая с месяца Month
+// до следующего курса этой валюты
+// @Description Курс валюты к рублю, действующий с месяца month
+type Rate struct {
+	Currency string `json:"currency" example:"USD"`
+	Month    string `json:"month" example:"01-2025"`
+	// десятичная дробь в виде строки, чтобы не терять точность
+	Rate string `json:"rate" example:"92.5"`
+}
+
+// ValidateRate проверяет курс; курс базовой валюты всегда равен 1 и не задается
+func ValidateRate(r *Rate) error {
+	if err := ValidateCurrency(r.Currency); err != nil || r.Currency == DefaultCurrency {
+		return fmt.Errorf("[ValidateRate|currency] %w", fieldError("currency", ErrWrongCurrency))
+	}
+	if err := ValidateDate(r.Month); err != nil {
+		return fmt.Errorf("[ValidateRate|month] %w", fieldError("month", err))
+	}
+	if !decimalRate.MatchString(r.Rate) {
+		return fmt.Errorf("[ValidateRate|rate] %w", fieldError("rate", ErrWrongRate))
+	}
+	value, _ := new(big.Rat).SetString(r.Rate)
+	if value.Sign() <= 0 {
+		return fmt.Errorf("[ValidateRate|rate] %w", fieldError("rate", ErrWrongRate))
+	}
+	return nil
+}
+
+// rateAt - курс, действующий с месяца from
+type rateAt struct {
+	from  Month
+	value *big.Rat
+}
+
+// Rates - таблица курсов для пересчета сумм между валютами по месяцам
+type Rates struct {
+	byCurrency map[string][]rateAt
+}
+
+// NewRates строит таблицу из провалидированных курсов
+func NewRates(rates []Rate) (*Rates, error) {
+	t := &Rates{byCurrency: map[string][]rateAt{}}
+	for i := range rates {
+		if err := ValidateRate(&rates[i]); err != nil {
+			return nil, fmt.Errorf("[NewRates] %w", err)
+		}
+		from, _ := ParseMonth(rates[i].Month)
+		value, _ := new(big.Rat).SetString(rates[i].Rate)
+		t.byCurrency[rates[i].Currency] = append(t.byCurrency[rates[i].Currency], rateAt{from: from, value: value})
+	}
+	for _, history := range t.byCurrency {
+		sort.Slice(history, func(i, j int) bool { return history[i].from.Before(history[j].from) })
+	}
+	return t, nil
+}
+
+// rate возвращает курс валюты, действующий в месяце m
+func (t *Rates) rate(currency string, m Month) (*big.Rat, error) {
+	if currency == DefaultCurrency {
+		return big.NewRat(1, 1), nil
+	}
+	history := t.byCurrency[currency]
+	// последний курс, начавший действовать не позже месяца m
+	i := sort.Search(len(history), func(i int) bool { return history[i].from.After(m) })
+	if i == 0 {
+		return nil, fmt.Errorf("[Rates|%s %s] %w", currency, m, ErrMissingRate)
+	}
+	return history[i-1].value, nil
+}
+
+// Ratio возвращает множитель для перевода сумм из валюты from в валюту to по курсам, действующим в месяце m
+func (t *Rates) Ratio(from, to string, m Month) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+	fromRate, err := t.rate(from, m)
+	if err != nil {
+		return nil, err
+	}
+	toRate, err := t.rate(to, m)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).Quo(fromRate, toRate), nil
+}
+
+// Convert переводит сумму в валюту to по курсам, действующим в месяце m; результат - в минорных единицах без округления
+func (t *Rates) Convert(money Money, to string, m Month) (*big.Rat, error) {
+	ratio, err := t.Ratio(money.Currency, to, m)
+	if err != nil {
+		return nil, err
+	}
+	return ratio.Mul(ratio, money.Amount.Rat()), nil
+}
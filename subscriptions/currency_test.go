@@ -0,0 +1,122 @@
+package subscriptions
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestValidateRate(t *testing.T) {
+	tests := []struct {
+		name string
+		rate Rate
+		err  error
+	}{
+		{name: "valid", rate: Rate{Currency: "USD", Month: "01-2025", Rate: "92.5"}},
+		{name: "fraction", rate: Rate{Currency: "JPY", Month: "01-2025", Rate: "0.6125"}},
+		{name: "base currency", rate: Rate{Currency: DefaultCurrency, Month: "01-2025", Rate: "1"}, err: ErrWrongCurrency},
+		{name: "lowercase code", rate: Rate{Currency: "usd", Month: "01-2025", Rate: "92.5"}, err: ErrWrongCurrency},
+		{name: "wrong month", rate: Rate{Currency: "USD", Month: "2025-01", Rate: "92.5"}, err: ErrWrongFormatDate},
+		{name: "zero rate", rate: Rate{Currency: "USD", Month: "01-2025", Rate: "0"}, err: ErrWrongRate},
+		{name: "negative rate", rate: Rate{Currency: "USD", Month: "01-2025", Rate: "-92.5"}, err: ErrWrongRate},
+		{name: "not a number", rate: Rate{Currency: "USD", Month: "01-2025", Rate: "abc"}, err: ErrWrongRate},
+		{name: "exponent", rate: Rate{Currency: "USD", Month: "01-2025", Rate: "9.25e1"}, err: ErrWrongRate},
+		{name: "fraction form", rate: Rate{Currency: "USD", Month: "01-2025", Rate: "185/2"}, err: ErrWrongRate},
+		{name: "hex", rate: Rate{Currency: "USD", Month: "01-2025", Rate: "0x5c"}, err: ErrWrongRate},
+		{name: "too many digits", rate: Rate{Currency: "USD", Month: "01-2025", Rate: "12345678901"}, err: ErrWrongRate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateRate(&tt.rate); !errors.Is(err, tt.err) {
+				t.Errorf("ValidateRate error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRatesConvert(t *testing.T) {
+	// курсы переданы не по порядку месяцев
+	rates, err := NewRates([]Rate{
+		{Currency: "USD", Month: "03-2025", Rate: "100"},
+		{Currency: "USD", Month: "01-2025", Rate: "90"},
+		{Currency: "EUR", Month: "01-2025", Rate: "99.9"},
+	})
+	if err != nil {
+		t.Fatalf("NewRates: %v", err)
+	}
+	month := func(s string) Month {
+		m, err := ParseMonth(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	tests := []struct {
+		name  string
+		money Money
+		to    string
+		month string
+		want  *big.Rat
+		err   error
+	}{
+		{name: "same currency", money: Money{Amount: 1000, Currency: "USD"}, to: "USD", month: "01-2020", want: big.NewRat(1000, 1)},
+		{name: "to base", money: Money{Amount: 1000, Currency: "USD"}, to: DefaultCurrency, month: "01-2025", want: big.NewRat(90000, 1)},
+		{name: "previous rate still active", money: Money{Amount: 1000, Currency: "USD"}, to: DefaultCurrency, month: "02-2025", want: big.NewRat(90000, 1)},
+		{name: "next rate", money: Money{Amount: 1000, Currency: "USD"}, to: DefaultCurrency, month: "07-2025", want: big.NewRat(100000, 1)},
+		{name: "from base", money: Money{Amount: 9000, Currency: DefaultCurrency}, to: "USD", month: "01-2025", want: big.NewRat(100, 1)},
+		{name: "cross rate", money: Money{Amount: 999, Currency: "EUR"}, to: "USD", month: "03-2025", want: big.NewRat(99900*999, 100*1000)},
+		{name: "before first rate", money: Money{Amount: 1000, Currency: "USD"}, to: DefaultCurrency, month: "12-2024", err: ErrMissingRate},
+		{name: "unknown currency", money: Money{Amount: 1000, Currency: DefaultCurrency}, to: "GBP", month: "01-2025", err: ErrMissingRate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rates.Convert(tt.money, tt.to, month(tt.month))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Convert error = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && got.Cmp(tt.want) != 0 {
+				t.Errorf("Convert = %s, want %s", got.RatString(), tt.want.RatString())
+			}
+		})
+	}
+}
+
+func TestNewRatesInvalid(t *testing.T) {
+	if _, err := NewRates([]Rate{{Currency: "USD", Month: "01-2025", Rate: "90"}, {Currency: "USD", Month: "02-2025"}}); !errors.Is(err, ErrWrongRate) {
+		t.Errorf("NewRates error = %v, want %v", err, ErrWrongRate)
+	}
+}
+
+func TestBreakdownCurrency(t *testing.T) {
+	rates, err := NewRates([]Rate{{Currency: "USD", Month: "01-2025", Rate: "90"}, {Currency: "USD", Month: "02-2025", Rate: "100"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	usd := testSubscription(t, 1000, BillingMonthly, "01-2025", "")
+	usd.Currency = "USD"
+	rub := testSubscription(t, 39900, BillingMonthly, "01-2025", "")
+
+	// пересчет в рубли по курсу каждого месяца
+	period := Period{Start: Month{Year: 2025, Month: 1}, End: Month{Year: 2025, Month: 2}}
+	b := NewBreakdown(period, DefaultCurrency, rates, ChargeActual)
+	for _, sub := range []*Subscription{usd, rub} {
+		if err := b.Add(sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+	series, err := b.Series()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if series[0].Total != 90000+39900 || series[1].Total != 100000+39900 {
+		t.Errorf("Series() = %+v", series)
+	}
+
+	// месяц без курса не считается
+	early := Period{Start: Month{Year: 2024, Month: 12}, End: Month{Year: 2025, Month: 1}}
+	usd.StartDate = "12-2024"
+	if err := NewBreakdown(early, DefaultCurrency, rates, ChargeActual).Add(usd); !errors.Is(err, ErrMissingRate) {
+		t.Errorf("Add error = %v, want %v", err, ErrMissingRate)
+	}
+}
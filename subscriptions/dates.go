@@ -0,0 +1,93 @@
+package subscriptions
+
+import (
+	"fmt"
+	"time"
+)
+
+// Date - дата начала или окончания подписки. Day == 0 - дата с точностью до месяца (MM-YYYY):
+// подписка начинается с первого дня месяца или заканчивается последним днем месяца
+type Date struct {
+	Month Month
+	Day   int
+}
+
+// ParseDate разбирает дату подписки в формате MM-YYYY или YYYY-MM-DD
+func ParseDate(s string) (Date, error) {
+	if len(s) != len(time.DateOnly) {
+		month, err := ParseMonth(s)
+		if err != nil {
+			return Date{}, err
+		}
+		return Date{Month: month}, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil || t.Year() < 2000 {
+		return Date{}, fmt.Errorf("[ParseDate] %w", ErrWrongFormatDate)
+	}
+	return Date{Month: MonthOf(t), Day: t.Day()}, nil
+}
+
+// String возвращает дату в том же формате, в котором она была задана
+func (d Date) String() string {
+	if d.Day == 0 {
+		return d.Month.String()
+	}
+	return d.Month.Time().AddDate(0, 0, d.Day-1).Format(time.DateOnly)
+}
+
+// firstDay - первый день подписки в месяце даты, если это дата начала
+func (d Date) firstDay() int {
+	if d.Day == 0 {
+		return 1
+	}
+	return d.Day
+}
+
+// lastDay - последний день подписки в месяце даты, если это дата окончания
+func (d Date) lastDay() int {
+	if d.Day == 0 {
+		return daysIn(d.Month)
+	}
+	return d.Day
+}
+
+// ActiveDates возвращает даты начала и окончания подписки
+func (s *Subscription) ActiveDates() (Date, *Date, error) {
+	start, err := ParseDate(s.StartDate)
+	if err != nil {
+		return Date{}, nil, err
+	}
+	if s.EndDate == nil {
+		return start, nil, nil
+	}
+	end, err := ParseDate(*s.EndDate)
+	if err != nil {
+		return Date{}, nil, err
+	}
+	return start, &end, nil
+}
+
+// activeDays возвращает первый и последний день месяца m, в которые подписка активна;
+// месяц должен входить в активный период подписки
+func activeDays(start Date, end *Date, m Month) (int, int) {
+	from, to := 1, daysIn(m)
+	if m == start.Month {
+		from = start.firstDay()
+	}
+	if end != nil && m == end.Month {
+		to = end.lastDay()
+	}
+	return from, to
+}
+
+// Days возвращает дни месяца дат начала и окончания для хранения в БД; 0 - дата с точностью до месяца.
+// Даты должны быть провалидированы
+func (s *Subscription) Days() (int, int) {
+	start, end, _ := s.ActiveDates()
+	if end == nil {
+		return start.Day, 0
+	}
+	return start.Day, end.Day
+}
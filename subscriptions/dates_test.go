@@ -0,0 +1,112 @@
+package subscriptions
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Date
+		err  error
+	}{
+		{in: "01-2025", want: Date{Month: Month{Year: 2025, Month: 1}}},
+		{in: "2025-01-15", want: Date{Month: Month{Year: 2025, Month: 1}, Day: 15}},
+		{in: "2024-02-29", want: Date{Month: Month{Year: 2024, Month: 2}, Day: 29}},
+		{in: "2025-02-29", err: ErrWrongFormatDate},
+		{in: "1999-12-31", err: ErrWrongFormatDate},
+		{in: "13-2025", err: ErrWrongFormatDate},
+		{in: "2025/01/15", err: ErrWrongFormatDate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseDate(tt.in)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseDate(%q) error = %v, want %v", tt.in, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDate(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+			if err == nil && got.String() != tt.in {
+				t.Errorf("ParseDate(%q).String() = %q", tt.in, got.String())
+			}
+		})
+	}
+}
+
+func TestValidateDates(t *testing.T) {
+	tests := []struct {
+		name  string
+		start string
+		end   string
+		err   error
+	}{
+		{name: "months", start: "01-2025", end: "03-2025"},
+		{name: "same month", start: "01-2025", end: "01-2025"},
+		{name: "days", start: "2025-01-15", end: "2025-03-10"},
+		{name: "same day", start: "2025-01-15", end: "2025-01-15"},
+		{name: "day before month end", start: "2025-01-15", end: "01-2025"},
+		{name: "month before first day", start: "01-2025", end: "2025-01-01"},
+		{name: "end day before start day", start: "2025-01-15", end: "2025-01-14", err: ErrWrongDatesInterval},
+		{name: "end month before start", start: "2025-02-01", end: "01-2025", err: ErrWrongDatesInterval},
+		{name: "wrong end", start: "01-2025", end: "2025-02-30", err: ErrWrongFormatDate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end := tt.end
+			if err := validateDates(tt.start, &end); !errors.Is(err, tt.err) {
+				t.Errorf("validateDates(%q, %q) error = %v, want %v", tt.start, tt.end, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestDateRoundTrip(t *testing.T) {
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		name      string
+		start     string
+		end       *string
+		startDay  int
+		endDay    int
+		startTime string
+		endTime   string
+	}{
+		{name: "months", start: "01-2025", end: str("03-2025"), startTime: "2025-01-01", endTime: "2025-03-01"},
+		{name: "open-ended month", start: "01-2025", startTime: "2025-01-01"},
+		{name: "open-ended day", start: "2025-01-15", startDay: 15, startTime: "2025-01-01"},
+		{name: "days", start: "2025-01-15", end: str("2025-03-10"), startDay: 15, endDay: 10, startTime: "2025-01-01", endTime: "2025-03-01"},
+		{name: "day to month", start: "2025-01-15", end: str("03-2025"), startDay: 15, startTime: "2025-01-01", endTime: "2025-03-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := &Subscription{StartDate: tt.start, EndDate: tt.end}
+			start, end, err := sub.DateRange()
+			if err != nil {
+				t.Fatalf("DateRange: %v", err)
+			}
+			if got := start.Format(time.DateOnly); got != tt.startTime {
+				t.Errorf("start_date = %s, want %s", got, tt.startTime)
+			}
+			if (end == nil) != (tt.endTime == "") || (end != nil && end.Format(time.DateOnly) != tt.endTime) {
+				t.Errorf("end_date = %v, want %q", end, tt.endTime)
+			}
+			startDay, endDay := sub.Days()
+			if startDay != tt.startDay || endDay != tt.endDay {
+				t.Errorf("Days() = %d, %d, want %d, %d", startDay, endDay, tt.startDay, tt.endDay)
+			}
+
+			// прочитанная из БД подписка сохраняет формат, в котором даты были заданы
+			var read Subscription
+			read.SetDateRange(start, end, startDay, endDay)
+			if read.StartDate != tt.start {
+				t.Errorf("StartDate = %q, want %q", read.StartDate, tt.start)
+			}
+			if (read.EndDate == nil) != (tt.end == nil) || (tt.end != nil && *read.EndDate != *tt.end) {
+				t.Errorf("EndDate = %v, want %v", read.EndDate, tt.end)
+			}
+		})
+	}
+}
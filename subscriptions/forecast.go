@@ -0,0 +1,80 @@
+package subscriptions
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// горизонт прогноза списаний в месяцах
+const (
+	DefaultForecastMonths = 6
+	MaxForecastMonths     = 36
+)
+
+var (
+	ErrWrongForecastMonths = errors.New("wrong forecast months")
+)
+
+// ForecastFilter описывает параметры прогноза списаний на остаток текущего месяца и Months месяцев вперед
+type ForecastFilter struct {
+	Months int
+	UserID uuid.UUID
+	// Currency - валюта, в которую пересчитываются списания, по умолчанию RUB
+	Currency string
+}
+
+// ValidateForecastFilter проверяет параметры прогноза и проставляет значения по умолчанию
+func ValidateForecastFilter(f *ForecastFilter) error {
+	if f.Months == 0 {
+		f.Months = DefaultForecastMonths
+	}
+	if f.Months < 0 || f.Months > MaxForecastMonths {
+		return fmt.Errorf("[ValidateForecastFilter|months] %w", fieldError("months", ErrWrongForecastMonths))
+	}
+
+	if f.Currency == "" {
+		f.Currency = DefaultCurrency
+	}
+	if err := ValidateCurrency(f.Currency); err != nil {
+		return fmt.Errorf("[ValidateForecastFilter|currency] %w", fieldError("currency", err))
+	}
+	return nil
+}
+
+// Window возвращает первый и последний день прогноза: с дня, следующего за now, по последний день
+// Months-го месяца после текущего. Списания текущего месяца до now включительно уже прошли и не учитываются
+func (f *ForecastFilter) Window(now time.Time) (Date, Date) {
+	tomorrow := now.AddDate(0, 0, 1)
+	last := MonthOf(now).AddMonths(f.Months)
+	return Date{Month: MonthOf(tomorrow), Day: tomorrow.Day()}, Date{Month: last, Day: daysIn(last)}
+}
+
+// TotalFilter возвращает фильтр подсчета фактических списаний за месяцы окна прогноза from - to.
+// В период попадают только подписки, не закончившиеся до его начала
+func (f *ForecastFilter) TotalFilter(from, to Date) *TotalFilter {
+	return &TotalFilter{
+		StartDate: from.Month.String(),
+		EndDate:   to.Month.String(),
+		UserID:    f.UserID,
+		Mode:      TotalModeOverlap,
+		Currency:  f.Currency,
+	}
+}
+
+// Forecast - прогноз списаний по месяцам и сервисам
+// @Description Прогноз списаний на ближайшие месяцы
+type Forecast struct {
+	// From и To - первый и последний день прогноза; первый месяц учитывается с From
+	From     string `json:"from" example:"2025-01-16"`
+	To       string `json:"to" example:"2025-07-31"`
+	Currency string `json:"currency" example:"RUB"`
+	// Total - сумма всех списаний за период прогноза
+	Total Amount `json:"total" swaggertype:"number" example:"2399.94"`
+	// Months - списания по месяцам
+	Months []MonthTotal `json:"months"`
+	// Services - списания по месяцам для каждого сервиса
+	Services []SeriesGroup `json:"services"`
+}
@@ -0,0 +1,79 @@
+package subscriptions
+
+import (
+	"testing"
+	"time"
+)
+
+func TestForecastWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      string
+		months   int
+		from, to string
+	}{
+		{"middle of month", "2025-01-15", 6, "2025-01-16", "2025-07-31"},
+		{"last day of month", "2025-01-31", 1, "2025-02-01", "2025-02-28"},
+		{"across year", "2025-12-31", 2, "2026-01-01", "2026-02-28"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now, _ := time.Parse(time.DateOnly, tt.now)
+			f := &ForecastFilter{Months: tt.months}
+			from, to := f.Window(now)
+			if from.String() != tt.from || to.String() != tt.to {
+				t.Errorf("Window(%s) = %s - %s, want %s - %s", tt.now, from, to, tt.from, tt.to)
+			}
+		})
+	}
+}
+
+func TestBreakdownFirstDay(t *testing.T) {
+	tests := []struct {
+		name     string
+		period   string
+		start    string
+		end      string
+		firstDay int
+		want     []MonthTotal
+	}{
+		{
+			name: "charge after first day", period: BillingMonthly, start: "2024-11-20", firstDay: 16,
+			want: []MonthTotal{{Month: "01-2025", Total: 100, Count: 1}, {Month: "02-2025", Total: 100, Count: 1}},
+		},
+		{
+			name: "charge before first day", period: BillingMonthly, start: "2024-11-10", firstDay: 16,
+			want: []MonthTotal{{Month: "01-2025", Total: 0, Count: 1}, {Month: "02-2025", Total: 100, Count: 1}},
+		},
+		{
+			name: "weekly after first day", period: BillingWeekly, start: "01-2025", firstDay: 16,
+			// 22 и 29 января
+			want: []MonthTotal{{Month: "01-2025", Total: 200, Count: 1}, {Month: "02-2025", Total: 400, Count: 1}},
+		},
+		{
+			name: "ended before first day", period: BillingWeekly, start: "01-2025", end: "2025-01-15", firstDay: 16,
+			want: []MonthTotal{{Month: "01-2025", Total: 0, Count: 0}, {Month: "02-2025", Total: 0, Count: 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := testSubscription(t, 100, tt.period, tt.start, tt.end)
+			start, _ := ParseMonth("01-2025")
+			end, _ := ParseMonth("02-2025")
+			b := NewBreakdown(Period{Start: start, End: end}, DefaultCurrency, &Rates{}, ChargeActual)
+			b.SetFirstDay(tt.firstDay)
+			if err := b.Add(sub); err != nil {
+				t.Fatal(err)
+			}
+			got, err := b.Series()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("Series()[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package subscriptions
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// операции журнала изменений подписки
+const (
+	EventCreate  = "create"
+	EventUpdate  = "update"
+	EventDelete  = "delete"
+	EventRestore = "restore"
+	// EventPurge - окончательное удаление по сроку хранения
+	EventPurge = "purge"
+	// EventPrice - назначение или отмена изменения цены
+	EventPrice = "price"
+)
+
+// Event - запись журнала изменений подписки
+// @Description Изменение подписки: кто, когда и что изменил
+type Event struct {
+	ID             int64     `json:"event_id" example:"42"`
+	SubscriptionID int       `json:"subscription_id" example:"7"`
+	Operation      string    `json:"operation" example:"update"`
+	Actor          string    `json:"actor" example:"jwt:60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	OccurredAt     time.Time `json:"occurred_at"`
+	// строка таблицы subscriptions до и после изменения; null для create и purge соответственно.
+	// Для price - строка subscription_price_periods; before заполнен, когда изменение заменено или отменено
+	Before json.RawMessage `json:"before" swaggertype:"object"`
+	After  json.RawMessage `json:"after" swaggertype:"object"`
+}
@@ -0,0 +1,100 @@
+package subscriptions
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+// допустимые поля сортировки списка подписок
+const (
+	SortByPrice       = "price"
+	SortByStartDate   = "start_date"
+	SortByServiceName = "service_name"
+)
+
+// допустимые направления сортировки
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+var (
+	ErrWrongLimit      = errors.New("wrong limit")
+	ErrWrongSortField  = errors.New("wrong sort field")
+	ErrWrongSortOrder  = errors.New("wrong sort order")
+	ErrWrongPriceRange = errors.New("price_min greater than price_max")
+)
+
+// ListFilter описывает параметры выборки списка подписок
+type ListFilter struct {
+	Limit       int
+	Cursor      string
+	SortBy      string
+	Order       string
+	UserID      uuid.UUID
+	ServiceName string
+	PriceMin    *Amount
+	PriceMax    *Amount
+	ActiveAt    string
+	// AsOf - момент, на который восстанавливается состояние подписок; nil - текущее состояние
+	AsOf *time.Time
+}
+
+// Page описывает одну страницу списка подписок
+// @Description Страница списка подписок
+type Page struct {
+	Items      []*Subscription `json:"items"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// ValidateListFilter проверяет параметры выборки и проставляет значения по умолчанию
+func ValidateListFilter(f *ListFilter) error {
+	// лимит по умолчанию
+	if f.Limit == 0 {
+		f.Limit = DefaultListLimit
+	}
+	if f.Limit < 0 || f.Limit > MaxListLimit {
+		return fmt.Errorf("[ValidateListFilter|limit] %w", fieldError("limit", ErrWrongLimit))
+	}
+
+	switch f.SortBy {
+	case "", SortByPrice, SortByStartDate, SortByServiceName:
+	default:
+		return fmt.Errorf("[ValidateListFilter|sort_by] %w", fieldError("sort_by", ErrWrongSortField))
+	}
+
+	switch f.Order {
+	case "":
+		f.Order = OrderAsc
+	case OrderAsc, OrderDesc:
+	default:
+		return fmt.Errorf("[ValidateListFilter|order] %w", fieldError("order", ErrWrongSortOrder))
+	}
+
+	// провалидируем диапазон цен
+	if f.PriceMin != nil && *f.PriceMin < 0 {
+		return fmt.Errorf("[ValidateListFilter|price_min] %w", fieldError("price_min", ErrWrongPrice))
+	}
+	if f.PriceMax != nil && *f.PriceMax < 0 {
+		return fmt.Errorf("[ValidateListFilter|price_max] %w", fieldError("price_max", ErrWrongPrice))
+	}
+	if f.PriceMin != nil && f.PriceMax != nil && *f.PriceMin > *f.PriceMax {
+		return fmt.Errorf("[ValidateListFilter|price] %w", fieldError("price_min", ErrWrongPriceRange))
+	}
+
+	if f.ActiveAt != "" {
+		if err := ValidateDate(f.ActiveAt); err != nil {
+			return fieldError("active_at", err)
+		}
+	}
+
+	return nil
+}
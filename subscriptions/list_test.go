@@ -0,0 +1,38 @@
+package subscriptions
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateListFilter(t *testing.T) {
+	amount := func(v Amount) *Amount { return &v }
+	tests := []struct {
+		name   string
+		filter ListFilter
+		err    error
+	}{
+		{name: "defaults", filter: ListFilter{}},
+		{name: "max limit", filter: ListFilter{Limit: MaxListLimit, SortBy: SortByPrice, Order: OrderDesc}},
+		{name: "limit too big", filter: ListFilter{Limit: MaxListLimit + 1}, err: ErrWrongLimit},
+		{name: "negative limit", filter: ListFilter{Limit: -1}, err: ErrWrongLimit},
+		{name: "wrong sort field", filter: ListFilter{SortBy: "user_id"}, err: ErrWrongSortField},
+		{name: "wrong order", filter: ListFilter{Order: "up"}, err: ErrWrongSortOrder},
+		{name: "price range", filter: ListFilter{PriceMin: amount(100), PriceMax: amount(100)}},
+		{name: "inverted price range", filter: ListFilter{PriceMin: amount(200), PriceMax: amount(100)}, err: ErrWrongPriceRange},
+		{name: "negative price", filter: ListFilter{PriceMin: amount(-1)}, err: ErrWrongPrice},
+		{name: "active at", filter: ListFilter{ActiveAt: "07-2025"}},
+		{name: "wrong active at", filter: ListFilter{ActiveAt: "2025-07"}, err: ErrWrongFormatDate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.filter
+			if err := ValidateListFilter(&f); !errors.Is(err, tt.err) {
+				t.Fatalf("ValidateListFilter error = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && (f.Limit == 0 || f.Order == "") {
+				t.Errorf("defaults not set: limit %d, order %q", f.Limit, f.Order)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package subscriptions
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrWrongAmount      = errors.New("wrong amount")
+	ErrAmountOverflow   = errors.New("amount out of range")
+	ErrWrongMoneyPolicy = errors.New("wrong money policy")
+)
+
+// minorUnits - число минорных единиц (копеек, центов) в единице валюты.
+// Для всех валют суммы хранятся с точностью до сотых
+const minorUnits = 100
+
+// Amount - денежная сумма в минорных единицах валюты
+type Amount int64
+
+// NumberPolicy задает, в каких единицах понимаются числа в JSON.
+// Строки ("399.99") всегда означают единицы валюты
+type NumberPolicy string
+
+const (
+	// NumbersMajor - числа в единицах валюты: 400 - 400 рублей, 399.99 - 399 рублей 99 копеек.
+	// Совместимо с клиентами, передававшими цену целыми рублями
+	NumbersMajor NumberPolicy = "major"
+	// NumbersMinor - числа в минорных единицах: 39999 - 399 рублей 99 копеек
+	NumbersMinor NumberPolicy = "minor"
+)
+
+// numberPolicy - политика чтения и записи чисел в JSON, задается при старте сервиса
+var numberPolicy = NumbersMajor
+
+// SetNumberPolicy задает политику чисел в JSON для всех сумм
+func SetNumberPolicy(p NumberPolicy) error {
+	if p != NumbersMajor && p != NumbersMinor {
+		return fmt.Errorf("[SetNumberPolicy|%s] %w", p, ErrWrongMoneyPolicy)
+	}
+	numberPolicy = p
+	return nil
+}
+
+// decimalAmount - десятичная запись суммы без экспоненты и префиксов систем счисления
+var decimalAmount = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// ParseAmount разбирает десятичную запись суммы в единицах валюты ("399.99", "400")
+func ParseAmount(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if !decimalAmount.MatchString(s) {
+		return 0, fmt.Errorf("[ParseAmount|%q] %w", s, ErrWrongAmount)
+	}
+	r, _ := new(big.Rat).SetString(s)
+	r.Mul(r, big.NewRat(minorUnits, 1))
+	if !r.IsInt() {
+		return 0, fmt.Errorf("[ParseAmount|%q] %w", s, ErrWrongAmount)
+	}
+	if !r.Num().IsInt64() {
+		return 0, fmt.Errorf("[ParseAmount|%q] %w", s, ErrAmountOverflow)
+	}
+	return Amount(r.Num().Int64()), nil
+}
+
+// String возвращает десятичную запись суммы в единицах валюты: "399.99", "400"
+func (a Amount) String() string {
+	sign := ""
+	v := uint64(a)
+	if a < 0 {
+		sign = "-"
+		v = -v
+	}
+	major, minor := v/minorUnits, v%minorUnits
+	if minor == 0 {
+		return sign + strconv.FormatUint(major, 10)
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, major, minor)
+}
+
+// Rat возвращает сумму в минорных единицах в виде дроби для точных вычислений
+func (a Amount) Rat() *big.Rat {
+	return new(big.Rat).SetInt64(int64(a))
+}
+
+// MarshalJSON пишет сумму числом по текущей политике
+func (a Amount) MarshalJSON() ([]byte, error) {
+	if numberPolicy == NumbersMinor {
+		return []byte(strconv.FormatInt(int64(a), 10)), nil
+	}
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON принимает строку с суммой в единицах валюты или число по текущей политике
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return fmt.Errorf("[Amount|unmarshal] %w", err)
+		}
+		v, err := ParseAmount(s)
+		if err != nil {
+			return err
+		}
+		*a = v
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("[Amount|unmarshal] %w", err)
+	}
+	if numberPolicy == NumbersMajor {
+		v, err := ParseAmount(n.String())
+		if err != nil {
+			return err
+		}
+		*a = v
+		return nil
+	}
+	v, err := strconv.ParseInt(n.String(), 10, 64)
+	if err != nil {
+		return fmt.Errorf("[Amount|%s] %w", n, ErrWrongAmount)
+	}
+	*a = Amount(v)
+	return nil
+}
+
+// RoundAmount округляет сумму в минорных единицах до целой, половины - от нуля
+func RoundAmount(r *big.Rat) (Amount, error) {
+	num := new(big.Int).Abs(r.Num())
+	quo, rem := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	if rem.Mul(rem, big.NewInt(2)).Cmp(r.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(1))
+	}
+	if r.Sign() < 0 {
+		quo.Neg(quo)
+	}
+	if !quo.IsInt64() {
+		return 0, fmt.Errorf("[RoundAmount] %w", ErrAmountOverflow)
+	}
+	return Amount(quo.Int64()), nil
+}
+
+// Money - сумма в минорных единицах вместе с валютой
+type Money struct {
+	Amount   Amount `json:"amount" swaggertype:"number" example:"399.99"`
+	Currency string `json:"currency" example:"RUB"`
+}
@@ -0,0 +1,143 @@
+package subscriptions
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Amount
+		err  error
+	}{
+		{in: "399.99", want: 39999},
+		{in: "400", want: 40000},
+		{in: "0.5", want: 50},
+		{in: "-1.50", want: -150},
+		{in: " 12.3 ", want: 1230},
+		{in: "1.005", err: ErrWrongAmount},
+		{in: "1e3", err: ErrWrongAmount},
+		{in: "0x10", err: ErrWrongAmount},
+		{in: "", err: ErrWrongAmount},
+		{in: "92233720368547758.08", err: ErrAmountOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseAmount(tt.in)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseAmount(%q) error = %v, want %v", tt.in, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseAmount(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundAmount(t *testing.T) {
+	huge := new(big.Rat).SetInt(new(big.Int).Lsh(big.NewInt(1), 63))
+	tests := []struct {
+		name string
+		in   *big.Rat
+		want Amount
+		err  error
+	}{
+		{name: "integer", in: big.NewRat(7, 1), want: 7},
+		{name: "half up", in: big.NewRat(1, 2), want: 1},
+		{name: "three halves", in: big.NewRat(3, 2), want: 2},
+		{name: "negative half away from zero", in: big.NewRat(-1, 2), want: -1},
+		{name: "below half", in: big.NewRat(5, 4), want: 1},
+		{name: "above half", in: big.NewRat(7, 4), want: 2},
+		{name: "overflow", in: huge, err: ErrAmountOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RoundAmount(tt.in)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("RoundAmount(%s) error = %v, want %v", tt.in, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("RoundAmount(%s) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  NumberPolicy
+		amount  Amount
+		encoded string
+	}{
+		{name: "major fraction", policy: NumbersMajor, amount: 39999, encoded: "399.99"},
+		{name: "major integer", policy: NumbersMajor, amount: 40000, encoded: "400"},
+		{name: "major negative", policy: NumbersMajor, amount: -50, encoded: "-0.50"},
+		{name: "minor", policy: NumbersMinor, amount: 39999, encoded: "39999"},
+	}
+	defer SetNumberPolicy(NumbersMajor)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetNumberPolicy(tt.policy); err != nil {
+				t.Fatal(err)
+			}
+			data, err := json.Marshal(tt.amount)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.encoded {
+				t.Errorf("Marshal(%d) = %s, want %s", tt.amount, data, tt.encoded)
+			}
+			var got Amount
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.amount {
+				t.Errorf("Unmarshal(%s) = %d, want %d", data, got, tt.amount)
+			}
+		})
+	}
+}
+
+func TestAmountUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy NumberPolicy
+		in     string
+		want   Amount
+		err    error
+	}{
+		{name: "major number", policy: NumbersMajor, in: "399.99", want: 39999},
+		{name: "major string", policy: NumbersMajor, in: `"399.99"`, want: 39999},
+		{name: "major too precise", policy: NumbersMajor, in: "0.001", err: ErrWrongAmount},
+		{name: "minor number", policy: NumbersMinor, in: "39999", want: 39999},
+		{name: "minor string is major", policy: NumbersMinor, in: `"399.99"`, want: 39999},
+		{name: "minor fraction", policy: NumbersMinor, in: "399.99", err: ErrWrongAmount},
+	}
+	defer SetNumberPolicy(NumbersMajor)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetNumberPolicy(tt.policy); err != nil {
+				t.Fatal(err)
+			}
+			var got Amount
+			err := json.Unmarshal([]byte(tt.in), &got)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Unmarshal(%s) error = %v, want %v", tt.in, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("Unmarshal(%s) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetNumberPolicy(t *testing.T) {
+	defer SetNumberPolicy(NumbersMajor)
+	if err := SetNumberPolicy("cents"); !errors.Is(err, ErrWrongMoneyPolicy) {
+		t.Errorf("SetNumberPolicy(cents) error = %v, want %v", err, ErrWrongMoneyPolicy)
+	}
+}
@@ -0,0 +1,68 @@
+package subscriptions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrWrongPatch = errors.New("merge patch must be a JSON object")
+)
+
+// MergePatch применяет к подписке JSON Merge Patch (RFC 7396) и возвращает результат.
+// Исходная подписка не изменяется; валидация результата остается за вызывающим
+func MergePatch(sub *Subscription, patch []byte) (*Subscription, error) {
+	var patchDoc interface{}
+	if err := json.Unmarshal(patch, &patchDoc); err != nil {
+		return nil, fmt.Errorf("[MergePatch|unmarshal patch] %w", err)
+	}
+	// патч подписки всегда объект: замена документа целиком не имеет смысла
+	if _, ok := patchDoc.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("[MergePatch] %w", ErrWrongPatch)
+	}
+
+	raw, err := json.Marshal(sub)
+	if err != nil {
+		return nil, fmt.Errorf("[MergePatch|marshal sub] %w", err)
+	}
+	var target interface{}
+	if err := json.Unmarshal(raw, &target); err != nil {
+		return nil, fmt.Errorf("[MergePatch|unmarshal sub] %w", err)
+	}
+
+	merged, err := json.Marshal(mergeValue(target, patchDoc))
+	if err != nil {
+		return nil, fmt.Errorf("[MergePatch|marshal merged] %w", err)
+	}
+
+	var result Subscription
+	if err := json.Unmarshal(merged, &result); err != nil {
+		return nil, fmt.Errorf("[MergePatch|unmarshal merged] %w", err)
+	}
+	// id и версия подписки патчем не меняются
+	result.ID = sub.ID
+	result.Version = sub.Version
+	return &result, nil
+}
+
+// mergeValue реализует алгоритм MergePatch из RFC 7396
+func mergeValue(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergeValue(targetObj[key], value)
+	}
+	return targetObj
+}
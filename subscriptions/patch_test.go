@@ -0,0 +1,93 @@
+package subscriptions
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gofrs/uuid"
+)
+
+func TestMergePatch(t *testing.T) {
+	userID := uuid.Must(uuid.FromString("60601fee-2bf1-4721-ae6f-7636e79a0cba"))
+	end := "12-2025"
+	original := &Subscription{
+		ID: 7, ServiceName: "Yandex Plus", Price: 39900, Currency: DefaultCurrency, BillingPeriod: BillingMonthly,
+		UserID: userID, StartDate: "01-2025", EndDate: &end, Version: 3,
+	}
+
+	tests := []struct {
+		name  string
+		patch string
+		check func(t *testing.T, got *Subscription)
+		err   error
+	}{
+		{
+			name: "price", patch: `{"price": "499.50"}`,
+			check: func(t *testing.T, got *Subscription) {
+				if got.Price != 49950 || got.ServiceName != "Yandex Plus" || got.EndDate == nil || *got.EndDate != end {
+					t.Errorf("got %+v", got)
+				}
+			},
+		},
+		{
+			name: "null removes end date", patch: `{"end_date": null}`,
+			check: func(t *testing.T, got *Subscription) {
+				if got.EndDate != nil || got.Price != 39900 {
+					t.Errorf("got %+v", got)
+				}
+			},
+		},
+		{
+			name: "id and version are kept", patch: `{"subscription_id": 99, "version": 99, "start_date": "2025-02-15"}`,
+			check: func(t *testing.T, got *Subscription) {
+				if got.ID != 7 || got.Version != 3 || got.StartDate != "2025-02-15" {
+					t.Errorf("got %+v", got)
+				}
+			},
+		},
+		{name: "array", patch: `[{"op": "replace"}]`, err: ErrWrongPatch},
+		{name: "null document", patch: `null`, err: ErrWrongPatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergePatch(original, []byte(tt.patch))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("MergePatch error = %v, want %v", err, tt.err)
+			}
+			if tt.check != nil {
+				tt.check(t, got)
+			}
+			if original.Price != 39900 || original.EndDate == nil || original.ID != 7 {
+				t.Errorf("original changed: %+v", original)
+			}
+		})
+	}
+
+	if _, err := MergePatch(original, []byte(`{"price":`)); err == nil {
+		t.Error("MergePatch accepted invalid JSON")
+	}
+}
+
+func TestMergePatchValidate(t *testing.T) {
+	original := &Subscription{ServiceName: "Yandex Plus", Price: 39900, StartDate: "03-2025", Version: 1}
+	tests := []struct {
+		patch string
+		err   error
+	}{
+		{patch: `{"end_date": "06-2025"}`},
+		{patch: `{"service_name": "  Yandex   Plus "}`},
+		{patch: `{"end_date": "01-2025"}`, err: ErrWrongDatesInterval},
+		{patch: `{"price": -1}`, err: ErrWrongPrice},
+		{patch: `{"service_name": null}`, err: ErrWrongServiceName},
+		{patch: `{"currency": "rub"}`, err: ErrWrongCurrency},
+	}
+	for _, tt := range tests {
+		got, err := MergePatch(original, []byte(tt.patch))
+		if err != nil {
+			t.Fatalf("MergePatch(%s): %v", tt.patch, err)
+		}
+		if err := Validate(got); !errors.Is(err, tt.err) {
+			t.Errorf("Validate after %s: error = %v, want %v", tt.patch, err, tt.err)
+		}
+	}
+}
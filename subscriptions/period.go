@@ -0,0 +1,142 @@
+package subscriptions
+
+import (
+	"fmt"
+	"time"
+)
+
+// Month - календарный месяц, единица тарификации подписок
+type Month struct {
+	Year  int
+	Month time.Month
+}
+
+// ParseMonth разбирает месяц в формате MM-YYYY
+func ParseMonth(s string) (Month, error) {
+	if err := ValidateDate(s); err != nil {
+		return Month{}, err
+	}
+	t, _ := time.Parse("01-2006", s)
+	return MonthOf(t), nil
+}
+
+// MonthOf возвращает месяц, в который попадает момент времени t
+func MonthOf(t time.Time) Month {
+	return Month{Year: t.Year(), Month: t.Month()}
+}
+
+// String возвращает месяц в формате MM-YYYY
+func (m Month) String() string {
+	return fmt.Sprintf("%02d-%04d", int(m.Month), m.Year)
+}
+
+// Time возвращает первое число месяца
+func (m Month) Time() time.Time {
+	return time.Date(m.Year, m.Month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// index - порядковый номер месяца для арифметики над месяцами
+func (m Month) index() int {
+	return m.Year*12 + int(m.Month) - 1
+}
+
+// AddMonths сдвигает месяц на n месяцев
+func (m Month) AddMonths(n int) Month {
+	i := m.index() + n
+	return Month{Year: i / 12, Month: time.Month(i%12 + 1)}
+}
+
+func (m Month) Before(o Month) bool {
+	return m.index() < o.index()
+}
+
+func (m Month) After(o Month) bool {
+	return m.index() > o.index()
+}
+
+// MonthsBetween возвращает число месяцев от from до to включительно
+func MonthsBetween(from, to Month) int {
+	return to.index() - from.index() + 1
+}
+
+// Period - отрезок месяцев, обе границы включены
+type Period struct {
+	Start Month
+	End   Month
+}
+
+// Months возвращает число месяцев в периоде
+func (p Period) Months() int {
+	return MonthsBetween(p.Start, p.End)
+}
+
+// Contains сообщает, попадает ли месяц в период
+func (p Period) Contains(m Month) bool {
+	return !m.Before(p.Start) && !m.After(p.End)
+}
+
+// Overlap возвращает пересечение периода с отрезком [start, end].
+// end == nil означает бессрочную подписку, которая обрезается концом периода
+func (p Period) Overlap(start Month, end *Month) (Period, bool) {
+	overlap := p
+	if start.After(overlap.Start) {
+		overlap.Start = start
+	}
+	if end != nil && end.Before(overlap.End) {
+		overlap.End = *end
+	}
+	if overlap.Start.After(overlap.End) {
+		return Period{}, false
+	}
+	return overlap, true
+}
+
+// ActivePeriod возвращает месяцы начала и окончания подписки
+func (s *Subscription) ActivePeriod() (Month, *Month, error) {
+	start, end, err := s.ActiveDates()
+	if err != nil {
+		return Month{}, nil, err
+	}
+	if end == nil {
+		return start.Month, nil, nil
+	}
+	return start.Month, &end.Month, nil
+}
+
+// OverlapMonths возвращает число месяцев подписки, попадающих в период
+func (s *Subscription) OverlapMonths(p Period) (int, error) {
+	start, end, err := s.ActivePeriod()
+	if err != nil {
+		return 0, err
+	}
+	overlap, ok := p.Overlap(start, end)
+	if !ok {
+		return 0, nil
+	}
+	return overlap.Months(), nil
+}
+
+// DateRange переводит даты подписки в первые числа месяцев, в виде которых они хранятся в БД;
+// дни месяца хранятся отдельно, см. Days
+func (s *Subscription) DateRange() (time.Time, *time.Time, error) {
+	start, end, err := s.ActivePeriod()
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	if end == nil {
+		return start.Time(), nil, nil
+	}
+	endTime := end.Time()
+	return start.Time(), &endTime, nil
+}
+
+// SetDateRange заполняет даты подписки из месяцев и дней, прочитанных из БД:
+// без дня дата записывается в формате MM-YYYY, с днем - YYYY-MM-DD
+func (s *Subscription) SetDateRange(start time.Time, end *time.Time, startDay, endDay int) {
+	s.StartDate = Date{Month: MonthOf(start), Day: startDay}.String()
+	s.EndDate = nil
+	if end != nil {
+		endDate := Date{Month: MonthOf(*end), Day: endDay}.String()
+		s.EndDate = &endDate
+	}
+}
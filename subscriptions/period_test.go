@@ -0,0 +1,79 @@
+package subscriptions
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOverlapMonths(t *testing.T) {
+	period := Period{Start: Month{Year: 2025, Month: 1}, End: Month{Year: 2025, Month: 6}}
+	tests := []struct {
+		name  string
+		start string
+		end   string
+		want  int
+	}{
+		{name: "inside", start: "02-2025", end: "04-2025", want: 3},
+		{name: "covers period", start: "01-2024", end: "12-2025", want: 6},
+		{name: "starts before", start: "10-2024", end: "02-2025", want: 2},
+		{name: "ends after", start: "05-2025", end: "09-2025", want: 2},
+		{name: "open-ended", start: "03-2025", want: 4},
+		{name: "single month on boundary", start: "06-2025", end: "06-2025", want: 1},
+		{name: "before period", start: "01-2024", end: "12-2024", want: 0},
+		{name: "after period", start: "07-2025", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := &Subscription{StartDate: tt.start}
+			if tt.end != "" {
+				sub.EndDate = &tt.end
+			}
+			got, err := sub.OverlapMonths(period)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("OverlapMonths = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonth(t *testing.T) {
+	m, err := ParseMonth("11-2024")
+	if err != nil {
+		t.Fatalf("ParseMonth: %v", err)
+	}
+	if m != (Month{Year: 2024, Month: time.November}) || m.String() != "11-2024" {
+		t.Errorf("ParseMonth = %+v (%s)", m, m)
+	}
+	if got := m.Time(); !got.Equal(time.Date(2024, time.November, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Time() = %v", got)
+	}
+	if got := MonthOf(time.Date(2024, time.November, 30, 23, 59, 0, 0, time.UTC)); got != m {
+		t.Errorf("MonthOf = %+v, want %+v", got, m)
+	}
+	if _, err := ParseMonth("13-2024"); err == nil {
+		t.Error("ParseMonth(13-2024) succeeded")
+	}
+
+	// арифметика над месяцами переходит через границу года
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{n: 0, want: "11-2024"},
+		{n: 2, want: "01-2025"},
+		{n: 14, want: "01-2026"},
+		{n: -11, want: "12-2023"},
+	}
+	for _, tt := range tests {
+		got := m.AddMonths(tt.n)
+		if got.String() != tt.want {
+			t.Errorf("AddMonths(%d) = %s, want %s", tt.n, got, tt.want)
+		}
+		if tt.n > 0 && (!got.After(m) || !m.Before(got) || MonthsBetween(m, got) != tt.n+1) {
+			t.Errorf("AddMonths(%d) = %s: wrong order or MonthsBetween = %d", tt.n, got, MonthsBetween(m, got))
+		}
+	}
+}
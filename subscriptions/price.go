@@ -0,0 +1,70 @@
+package subscriptions
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrWrongPriceMonth = errors.New("price change month out of range")
+)
+
+// PriceChange - цена подписки, действующая с месяца Month до следующего изменения цены или окончания подписки.
+// До первого изменения действует Subscription.Price
+// @Description Изменение цены подписки с месяца month
+type PriceChange struct {
+	Month string `json:"month" example:"03-2025"`
+	Price Amount `json:"price" swaggertype:"number" example:"499.99"`
+}
+
+// ValidatePriceChange проверяет изменение цены подписки sub: менять цену можно только с будущего месяца
+// (позже now), попадающего в активный период подписки и не совпадающего с месяцем ее начала
+func ValidatePriceChange(sub *Subscription, change *PriceChange, now Month) error {
+	month, err := ParseMonth(change.Month)
+	if err != nil {
+		return fmt.Errorf("[ValidatePriceChange|month] %w", fieldError("month", err))
+	}
+	if change.Price < 0 {
+		return fmt.Errorf("[ValidatePriceChange|price] %w", fieldError("price", ErrWrongPrice))
+	}
+
+	start, end, err := sub.ActivePeriod()
+	if err != nil {
+		return fmt.Errorf("[ValidatePriceChange] %w", err)
+	}
+	if !month.After(now) || !month.After(start) || (end != nil && month.After(*end)) {
+		return fmt.Errorf("[ValidatePriceChange|month] %w", fieldError("month", ErrWrongPriceMonth))
+	}
+	return nil
+}
+
+// priceAt - цена, действующая с месяца from
+type priceAt struct {
+	from  Month
+	price Amount
+}
+
+// SetPriceChanges задает изменения цены подписки, учитываемые при подсчете стоимости
+func (s *Subscription) SetPriceChanges(changes []PriceChange) error {
+	prices := make([]priceAt, 0, len(changes))
+	for _, change := range changes {
+		month, err := ParseMonth(change.Month)
+		if err != nil {
+			return fmt.Errorf("[SetPriceChanges|%s] %w", change.Month, err)
+		}
+		prices = append(prices, priceAt{from: month, price: change.Price})
+	}
+	sort.Slice(prices, func(i, j int) bool { return prices[i].from.Before(prices[j].from) })
+	s.prices = prices
+	return nil
+}
+
+// PriceIn возвращает цену подписки, действующую в месяце m
+func (s *Subscription) PriceIn(m Month) Amount {
+	i := sort.Search(len(s.prices), func(i int) bool { return s.prices[i].from.After(m) })
+	if i == 0 {
+		return s.Price
+	}
+	return s.prices[i-1].price
+}
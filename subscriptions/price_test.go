@@ -0,0 +1,28 @@
+package subscriptions
+
+import "testing"
+
+func TestPriceIn(t *testing.T) {
+	sub := testSubscription(t, 100, BillingMonthly, "01-2025", "")
+	err := sub.SetPriceChanges([]PriceChange{{Month: "06-2025", Price: 300}, {Month: "03-2025", Price: 200}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		month string
+		want  Amount
+	}{
+		{"01-2025", 100},
+		{"02-2025", 100},
+		{"03-2025", 200},
+		{"05-2025", 200},
+		{"06-2025", 300},
+		{"12-2026", 300},
+	}
+	for _, tt := range tests {
+		m, _ := ParseMonth(tt.month)
+		if got := sub.PriceIn(m); got != tt.want {
+			t.Errorf("PriceIn(%s) = %d, want %d", tt.month, got, tt.want)
+		}
+	}
+}
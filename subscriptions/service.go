@@ -0,0 +1,87 @@
+package subscriptions
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrWrongServiceName = errors.New("wrong service name")
+	ErrWrongCategory    = errors.New("wrong service category")
+	ErrWrongMergeSource = errors.New("wrong merge source service")
+)
+
+const (
+	// maxServiceName - длина названия сервиса и его синонимов, как в колонке service_name
+	maxServiceName = 32
+	maxCategory    = 64
+)
+
+// Service - запись справочника сервисов. Подписка ссылается на сервис по service_id,
+// а название из запроса (каноническое или синоним) заменяется каноническим
+// @Description Сервис из справочника
+type Service struct {
+	ID       int    `json:"service_id" readonly:"true"`
+	Name     string `json:"name" example:"Yandex Plus"`
+	Category string `json:"category,omitempty" example:"music"`
+	// Aliases - другие названия сервиса; сравниваются без учета регистра и лишних пробелов
+	Aliases []string `json:"aliases" example:"Яндекс Плюс"`
+}
+
+// ServiceMerge - слияние сервиса From с другим сервисом: его подписки и названия переходят к тому сервису
+// @Description Сервис, который сливается с выбранным
+type ServiceMerge struct {
+	From int `json:"from" example:"2"`
+}
+
+// ValidateServiceMerge проверяет, что сервис id сливается с другим сервисом
+func ValidateServiceMerge(id int, m *ServiceMerge) error {
+	if m.From <= 0 || m.From == id {
+		return fmt.Errorf("[ValidateServiceMerge|from] %w", fieldError("from", ErrWrongMergeSource))
+	}
+	return nil
+}
+
+// NormalizeServiceName убирает лишние пробелы в названии сервиса
+func NormalizeServiceName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// ServiceKey - ключ для сравнения названий сервисов, совпадает с функцией service_key в БД
+func ServiceKey(name string) string {
+	return strings.ToLower(NormalizeServiceName(name))
+}
+
+func validServiceName(name string) bool {
+	return name != "" && utf8.RuneCountInString(name) <= maxServiceName
+}
+
+// ValidateService проверяет запись справочника, нормализует названия и убирает повторяющиеся синонимы
+func ValidateService(s *Service) error {
+	s.Name = NormalizeServiceName(s.Name)
+	if !validServiceName(s.Name) {
+		return fmt.Errorf("[ValidateService|name] %w", fieldError("name", ErrWrongServiceName))
+	}
+	s.Category = strings.TrimSpace(s.Category)
+	if utf8.RuneCountInString(s.Category) > maxCategory {
+		return fmt.Errorf("[ValidateService|category] %w", fieldError("category", ErrWrongCategory))
+	}
+
+	seen := map[string]bool{ServiceKey(s.Name): true}
+	aliases := make([]string, 0, len(s.Aliases))
+	for _, alias := range s.Aliases {
+		alias = NormalizeServiceName(alias)
+		if !validServiceName(alias) {
+			return fmt.Errorf("[ValidateService|aliases] %w", fieldError("aliases", ErrWrongServiceName))
+		}
+		if seen[ServiceKey(alias)] {
+			continue
+		}
+		seen[ServiceKey(alias)] = true
+		aliases = append(aliases, alias)
+	}
+	s.Aliases = aliases
+	return nil
+}
@@ -0,0 +1,105 @@
+package subscriptions
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestServiceKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		normalized string
+		key        string
+	}{
+		{name: "Yandex Plus", normalized: "Yandex Plus", key: "yandex plus"},
+		{name: "  Yandex \t Plus ", normalized: "Yandex Plus", key: "yandex plus"},
+		{name: "Яндекс  Плюс", normalized: "Яндекс Плюс", key: "яндекс плюс"},
+		{name: "   ", normalized: "", key: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeServiceName(tt.name); got != tt.normalized {
+				t.Errorf("NormalizeServiceName(%q) = %q, want %q", tt.name, got, tt.normalized)
+			}
+			if got := ServiceKey(tt.name); got != tt.key {
+				t.Errorf("ServiceKey(%q) = %q, want %q", tt.name, got, tt.key)
+			}
+		})
+	}
+}
+
+func TestValidateService(t *testing.T) {
+	tests := []struct {
+		name    string
+		service Service
+		want    Service
+		err     error
+	}{
+		{
+			name:    "normalized",
+			service: Service{Name: " Yandex  Plus ", Category: " music ", Aliases: []string{"Яндекс  Плюс", "yandex plus", "ЯНДЕКС ПЛЮС", "Plus"}},
+			// синонимы, совпадающие с названием или друг с другом, убираются
+			want: Service{Name: "Yandex Plus", Category: "music", Aliases: []string{"Яндекс Плюс", "Plus"}},
+		},
+		{name: "without aliases", service: Service{Name: "Netflix"}, want: Service{Name: "Netflix", Aliases: []string{}}},
+		{name: "empty name", service: Service{Name: "  "}, err: ErrWrongServiceName},
+		{name: "long name", service: Service{Name: strings.Repeat("я", maxServiceName+1)}, err: ErrWrongServiceName},
+		{name: "empty alias", service: Service{Name: "Netflix", Aliases: []string{""}}, err: ErrWrongServiceName},
+		{name: "long category", service: Service{Name: "Netflix", Category: strings.Repeat("a", maxCategory+1)}, err: ErrWrongCategory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := tt.service
+			if err := ValidateService(&svc); !errors.Is(err, tt.err) {
+				t.Fatalf("ValidateService error = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && !reflect.DeepEqual(svc, tt.want) {
+				t.Errorf("ValidateService = %+v, want %+v", svc, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateServiceMerge(t *testing.T) {
+	tests := []struct {
+		from int
+		err  error
+	}{
+		{from: 2},
+		{from: 1, err: ErrWrongMergeSource},
+		{from: 0, err: ErrWrongMergeSource},
+	}
+	for _, tt := range tests {
+		if err := ValidateServiceMerge(1, &ServiceMerge{From: tt.from}); !errors.Is(err, tt.err) {
+			t.Errorf("ValidateServiceMerge(1, %d) error = %v, want %v", tt.from, err, tt.err)
+		}
+	}
+}
+
+func TestValidateSubscriptionService(t *testing.T) {
+	tests := []struct {
+		name string
+		sub  Subscription
+		want string
+		err  error
+	}{
+		{name: "by name", sub: Subscription{ServiceName: " Yandex   Plus", StartDate: "01-2025"}, want: "Yandex Plus"},
+		// название подставит БД по service_id
+		{name: "by id", sub: Subscription{ServiceID: 3, StartDate: "01-2025"}},
+		{name: "without service", sub: Subscription{StartDate: "01-2025"}, err: ErrWrongServiceName},
+		{name: "negative id", sub: Subscription{ServiceID: -1, ServiceName: "Netflix", StartDate: "01-2025"}, err: ErrWrongServiceName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := tt.sub
+			if err := Validate(&sub); !errors.Is(err, tt.err) {
+				t.Fatalf("Validate error = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && sub.ServiceName != tt.want {
+				t.Errorf("ServiceName = %q, want %q", sub.ServiceName, tt.want)
+			}
+		})
+	}
+}
@@ -16,35 +16,99 @@ var (
 	ErrWrongDatesInterval = errors.New("end_date befor start_date")
 )
 
+// FieldError связывает ошибку валидации с полем, в котором она обнаружена
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+func fieldError(field string, err error) error {
+	return &FieldError{Field: field, Err: err}
+}
+
 // Subdcription описывает запись о подписке
 // @Description Модель подписки
 type Subscription struct {
-	ServiceName string    `json:"service_name"`
-	Price       int       `json:"price"`
-	UserID      uuid.UUID `json:"user_id"`
-	StartDate   string    `json:"start_date" example:"01-2001"`
-	EndDate     *string   `json:"end_date,omitempty" example:"01-2001"`
+	ID int `json:"subscription_id" readonly:"true"`
+	// ServiceName - название сервиса или его синоним; при сохранении заменяется каноническим названием из справочника
+	ServiceName string `json:"service_name"`
+	// ServiceID - сервис из справочника; если задан, название берется из справочника
+	ServiceID int `json:"service_id,omitempty" example:"1"`
+	// Price - сумма одного списания в минорных единицах валюты; в JSON - число по политике NumberPolicy или строка "399.99"
+	Price Amount `json:"price" swaggertype:"number" example:"399.99"`
+	// Currency - код валюты ISO 4217, по умолчанию RUB
+	Currency string `json:"currency" example:"RUB"`
+	// BillingPeriod - период списания: weekly, monthly (по умолчанию), quarterly, yearly или custom
+	BillingPeriod string `json:"billing_period" example:"monthly" enums:"weekly,monthly,quarterly,yearly,custom"`
+	// BillingMonths - число месяцев между списаниями для custom
+	BillingMonths int       `json:"billing_months,omitempty" example:"6"`
+	UserID        uuid.UUID `json:"user_id"`
+	// StartDate - месяц (MM-YYYY) или день (YYYY-MM-DD) начала подписки
+	StartDate string `json:"start_date" example:"01-2001"`
+	// EndDate - месяц (MM-YYYY) или день (YYYY-MM-DD) окончания подписки включительно
+	EndDate *string `json:"end_date,omitempty" example:"01-2001"`
+	Version int     `json:"version" readonly:"true"`
+
+	// prices - изменения цены, загруженные для подсчета стоимости; см. SetPriceChanges
+	prices []priceAt
+}
+
+// Money возвращает цену подписки вместе с валютой
+func (s *Subscription) Money() Money {
+	return Money{Amount: s.Price, Currency: s.Currency}
 }
 
 func Validate(sub *Subscription) error {
+	// сервис задается id или названием
+	if sub.ServiceID < 0 {
+		return fmt.Errorf("[Validate|service_id] %w", fieldError("service_id", ErrWrongServiceName))
+	}
+	sub.ServiceName = NormalizeServiceName(sub.ServiceName)
+	if sub.ServiceID == 0 && !validServiceName(sub.ServiceName) {
+		return fmt.Errorf("[Validate|service_name] %w", fieldError("service_name", ErrWrongServiceName))
+	}
 	// провалидируем цену
 	if sub.Price < 0 {
-		return fmt.Errorf("[Validate|price] %w", ErrWrongPrice)
+		return fmt.Errorf("[Validate|price] %w", fieldError("price", ErrWrongPrice))
 	}
-	// валидация дат
-	if err := ValidateDate(sub.StartDate); err != nil {
+	// провалидируем валюту
+	if sub.Currency == "" {
+		sub.Currency = DefaultCurrency
+	}
+	if err := ValidateCurrency(sub.Currency); err != nil {
+		return fmt.Errorf("[Validate|currency] %w", fieldError("currency", err))
+	}
+	// провалидируем период списания
+	if err := ValidateBilling(sub); err != nil {
 		return err
 	}
-	if sub.EndDate != nil {
-		if err := ValidateDate(*sub.EndDate); err != nil {
-			return err
+	// валидация дат
+	return validateDates(sub.StartDate, sub.EndDate)
+}
+
+// validateDates проверяет формат дат начала и окончания (MM-YYYY или YYYY-MM-DD) и их порядок
+func validateDates(startDate string, endDate *string) error {
+	start, err := ParseDate(startDate)
+	if err != nil {
+		return fieldError("start_date", err)
+	}
+	if endDate != nil {
+		end, err := ParseDate(*endDate)
+		if err != nil {
+			return fieldError("end_date", err)
 		}
 
-		// проверка на то, что end_date > start_date
-		startParse, _ := time.Parse("01-2006", sub.StartDate)
-		endParse, _ := time.Parse("01-2006", *sub.EndDate)
-		if endParse.Before(startParse) {
-			return fmt.Errorf("[Validate|dates] %w", ErrWrongDatesInterval)
+		// проверка на то, что последний день подписки не раньше первого
+		if end.Month.Before(start.Month) || (end.Month == start.Month && end.lastDay() < start.firstDay()) {
+			return fmt.Errorf("[Validate|dates] %w", fieldError("end_date", ErrWrongDatesInterval))
 		}
 	}
 
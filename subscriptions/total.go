@@ -0,0 +1,227 @@
+package subscriptions
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// режимы подсчета суммарной стоимости подписок за период
+const (
+	// TotalModeOverlap - учитываются списания подписки в ее месяцах, попавших в период
+	TotalModeOverlap = "overlap-months"
+	// TotalModeContained - учитываются только подписки, целиком лежащие в периоде, по одному списанию за подписку
+	TotalModeContained = "contained"
+	// TotalModeProrated - как overlap-months, но неполные месяцы подписки учитываются пропорционально числу активных дней
+	TotalModeProrated = "prorated"
+)
+
+var (
+	ErrWrongTotalMode = errors.New("wrong total mode")
+)
+
+// TotalFilter описывает параметры подсчета суммарной стоимости подписок
+type TotalFilter struct {
+	StartDate   string
+	EndDate     string
+	UserID      uuid.UUID
+	ServiceName string
+	Mode        string
+	// Currency - валюта, в которую пересчитывается стоимость подписок, по умолчанию RUB
+	Currency string
+	// Amortize - распределять списания равномерно по месяцам периода списания вместо учета фактических списаний
+	Amortize bool
+	// AsOf - момент, на который восстанавливается состояние подписок; nil - текущее состояние
+	AsOf *time.Time
+}
+
+// ValidateTotalFilter проверяет параметры подсчета и проставляет режим по умолчанию
+func ValidateTotalFilter(f *TotalFilter) error {
+	// период задается месяцами; порядок дат проверяем так же, как у подписки
+	if err := ValidateDate(f.StartDate); err != nil {
+		return fmt.Errorf("[ValidateTotalFilter|start_date] %w", fieldError("start_date", err))
+	}
+	if err := ValidateDate(f.EndDate); err != nil {
+		return fmt.Errorf("[ValidateTotalFilter|end_date] %w", fieldError("end_date", err))
+	}
+	endDate := f.EndDate
+	if err := validateDates(f.StartDate, &endDate); err != nil {
+		return err
+	}
+
+	if f.Currency == "" {
+		f.Currency = DefaultCurrency
+	}
+	if err := ValidateCurrency(f.Currency); err != nil {
+		return fmt.Errorf("[ValidateTotalFilter|currency] %w", fieldError("currency", err))
+	}
+
+	switch f.Mode {
+	case "":
+		f.Mode = TotalModeOverlap
+	case TotalModeOverlap, TotalModeContained, TotalModeProrated:
+	default:
+		return fmt.Errorf("[ValidateTotalFilter|mode] %w", fieldError("mode", ErrWrongTotalMode))
+	}
+
+	return nil
+}
+
+// Basis возвращает способ отнесения списаний к месяцам для режима и флага Amortize
+func (f *TotalFilter) Basis() ChargeBasis {
+	switch {
+	case f.Mode == TotalModeProrated:
+		return ChargeProrated
+	case f.Amortize:
+		return ChargeAmortized
+	}
+	return ChargeActual
+}
+
+// Period возвращает период подсчета; фильтр должен быть провалидирован
+func (f *TotalFilter) Period() Period {
+	start, _ := ParseMonth(f.StartDate)
+	end, _ := ParseMonth(f.EndDate)
+	return Period{Start: start, End: end}
+}
+
+// MaxBreakdownMonths ограничивает длину периода помесячной разбивки
+const MaxBreakdownMonths = 120
+
+// допустимые поля группировки помесячной разбивки
+const (
+	GroupByServiceName = "service_name"
+	GroupByUserID      = "user_id"
+)
+
+var (
+	ErrWrongGroupBy  = errors.New("wrong group_by field")
+	ErrPeriodTooLong = errors.New("period is too long")
+	// ErrWrongBreakdownMode - режим contained считает одно списание на подписку и не раскладывается по месяцам
+	ErrWrongBreakdownMode = errors.New("wrong breakdown mode")
+)
+
+// ValidateBreakdown проверяет режим, длину периода и поле группировки помесячной разбивки;
+// фильтр должен быть предварительно провалидирован ValidateTotalFilter
+func ValidateBreakdown(f *TotalFilter, groupBy string) error {
+	if f.Mode == TotalModeContained {
+		return fmt.Errorf("[ValidateBreakdown|mode] %w", fieldError("mode", ErrWrongBreakdownMode))
+	}
+	if f.Period().Months() > MaxBreakdownMonths {
+		return fmt.Errorf("[ValidateBreakdown|period] %w", fieldError("end_date", ErrPeriodTooLong))
+	}
+
+	switch groupBy {
+	case "", GroupByServiceName, GroupByUserID:
+		return nil
+	}
+	return fmt.Errorf("[ValidateBreakdown|group_by] %w", fieldError("group_by", ErrWrongGroupBy))
+}
+
+// MonthTotal - суммарная стоимость и число активных подписок за месяц
+// @Description Сумма подписок за месяц
+type MonthTotal struct {
+	Month string `json:"month" example:"01-2025"`
+	Total Amount `json:"total" swaggertype:"number" example:"1234.5"`
+	Count int    `json:"count" example:"7"`
+}
+
+// SeriesGroup - помесячная разбивка для одной группы подписок
+// @Description Помесячная разбивка для группы подписок
+type SeriesGroup struct {
+	Group  string       `json:"group" example:"Yandex Plus"`
+	Series []MonthTotal `json:"series"`
+}
+
+// Breakdown накапливает помесячные суммы подписок за период в валюте currency
+type Breakdown struct {
+	period   Period
+	currency string
+	rates    *Rates
+	basis    ChargeBasis
+	// firstDay - первый учитываемый день первого месяца периода
+	firstDay int
+	series   []MonthTotal
+	totals   []*big.Rat
+}
+
+// NewBreakdown создает разбивку, в которой каждый месяц периода заполнен нулями.
+// Списания подписок пересчитываются в currency по курсам rates, действующим в каждом месяце;
+// basis задает способ отнесения списаний к месяцам
+func NewBreakdown(p Period, currency string, rates *Rates, basis ChargeBasis) *Breakdown {
+	series := make([]MonthTotal, 0, p.Months())
+	totals := make([]*big.Rat, 0, p.Months())
+	for m := p.Start; !m.After(p.End); m = m.AddMonths(1) {
+		series = append(series, MonthTotal{Month: m.String()})
+		totals = append(totals, new(big.Rat))
+	}
+	return &Breakdown{period: p, currency: currency, rates: rates, basis: basis, firstDay: 1, series: series, totals: totals}
+}
+
+// SetFirstDay ограничивает первый месяц периода днями начиная с day: списания до него не учитываются,
+// а подписки, закончившиеся раньше, не считаются активными
+func (b *Breakdown) SetFirstDay(day int) {
+	b.firstDay = day
+}
+
+// Add учитывает списания подписки во всех ее месяцах, попавших в период; Count считает месяцы, в которых подписка активна
+func (b *Breakdown) Add(sub *Subscription) error {
+	start, end, err := sub.ActiveDates()
+	if err != nil {
+		return err
+	}
+	var endMonth *Month
+	if end != nil {
+		endMonth = &end.Month
+	}
+	overlap, ok := b.period.Overlap(start.Month, endMonth)
+	if !ok {
+		return nil
+	}
+	for m := overlap.Start; !m.After(overlap.End); m = m.AddMonths(1) {
+		since := 1
+		if m == b.period.Start {
+			if _, to := activeDays(start, end, m); to < b.firstDay {
+				continue
+			}
+			since = b.firstDay
+		}
+		charge, err := sub.chargeSince(m, since, b.basis)
+		if err != nil {
+			return err
+		}
+		ratio, err := b.rates.Ratio(sub.Currency, b.currency, m)
+		if err != nil {
+			return err
+		}
+		price := charge.Mul(charge, ratio)
+		i := MonthsBetween(b.period.Start, m) - 1
+		b.totals[i].Add(b.totals[i], price)
+		b.series[i].Count++
+	}
+	return nil
+}
+
+// Total возвращает сумму за весь период; округляется только итог, а не каждый месяц
+func (b *Breakdown) Total() (Amount, error) {
+	sum := new(big.Rat)
+	for _, total := range b.totals {
+		sum.Add(sum, total)
+	}
+	return RoundAmount(sum)
+}
+
+// Series возвращает помесячную разбивку; суммы округляются до минорных единиц
+func (b *Breakdown) Series() ([]MonthTotal, error) {
+	for i := range b.series {
+		total, err := RoundAmount(b.totals[i])
+		if err != nil {
+			return nil, fmt.Errorf("[Series|%s] %w", b.series[i].Month, err)
+		}
+		b.series[i].Total = total
+	}
+	return b.series, nil
+}
@@ -0,0 +1,124 @@
+package subscriptions
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBreakdownTotal(t *testing.T) {
+	period := Period{Start: Month{Year: 2025, Month: 1}, End: Month{Year: 2025, Month: 3}}
+	b := NewBreakdown(period, DefaultCurrency, &Rates{}, ChargeActual)
+	for _, sub := range []*Subscription{
+		// два месяца в периоде
+		testSubscription(t, 40000, BillingMonthly, "12-2024", "02-2025"),
+		// весь период
+		testSubscription(t, 19900, BillingMonthly, "01-2025", ""),
+		// вне периода
+		testSubscription(t, 99900, BillingMonthly, "04-2025", ""),
+	} {
+		if err := b.Add(sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+	total, err := b.Total()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := Amount(2*40000 + 3*19900); total != want {
+		t.Errorf("Total() = %d, want %d", total, want)
+	}
+}
+
+func TestValidateTotalFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter TotalFilter
+		mode   string
+		err    error
+	}{
+		{name: "default mode", filter: TotalFilter{StartDate: "01-2025", EndDate: "12-2025"}, mode: TotalModeOverlap},
+		{name: "contained", filter: TotalFilter{StartDate: "01-2025", EndDate: "01-2025", Mode: TotalModeContained}, mode: TotalModeContained},
+		{name: "prorated", filter: TotalFilter{StartDate: "01-2025", EndDate: "03-2025", Mode: TotalModeProrated}, mode: TotalModeProrated},
+		{name: "wrong mode", filter: TotalFilter{StartDate: "01-2025", EndDate: "03-2025", Mode: "sum"}, err: ErrWrongTotalMode},
+		{name: "inverted period", filter: TotalFilter{StartDate: "03-2025", EndDate: "01-2025"}, err: ErrWrongDatesInterval},
+		{name: "day precision period", filter: TotalFilter{StartDate: "2025-01-15", EndDate: "03-2025"}, err: ErrWrongFormatDate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.filter
+			if err := ValidateTotalFilter(&f); !errors.Is(err, tt.err) {
+				t.Fatalf("ValidateTotalFilter error = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && f.Mode != tt.mode {
+				t.Errorf("Mode = %q, want %q", f.Mode, tt.mode)
+			}
+		})
+	}
+}
+
+func TestTotalFilterPeriod(t *testing.T) {
+	f := TotalFilter{StartDate: "11-2024", EndDate: "02-2025"}
+	want := Period{Start: Month{Year: 2024, Month: 11}, End: Month{Year: 2025, Month: 2}}
+	if got := f.Period(); got != want || got.Months() != 4 {
+		t.Errorf("Period() = %+v (%d months), want %+v", got, got.Months(), want)
+	}
+}
+
+func TestValidateBreakdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  TotalFilter
+		groupBy string
+		err     error
+	}{
+		{name: "overlap", filter: TotalFilter{StartDate: "01-2025", EndDate: "12-2025"}},
+		{name: "group by service", filter: TotalFilter{StartDate: "01-2025", EndDate: "12-2025"}, groupBy: GroupByServiceName},
+		{name: "group by user", filter: TotalFilter{StartDate: "01-2025", EndDate: "12-2025", Mode: TotalModeProrated}, groupBy: GroupByUserID},
+		{name: "max period", filter: TotalFilter{StartDate: "01-2015", EndDate: "12-2024"}},
+		{name: "period too long", filter: TotalFilter{StartDate: "01-2015", EndDate: "01-2025"}, err: ErrPeriodTooLong},
+		{name: "contained", filter: TotalFilter{StartDate: "01-2025", EndDate: "12-2025", Mode: TotalModeContained}, err: ErrWrongBreakdownMode},
+		{name: "wrong group by", filter: TotalFilter{StartDate: "01-2025", EndDate: "12-2025"}, groupBy: "price", err: ErrWrongGroupBy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.filter
+			if err := ValidateTotalFilter(&f); err != nil {
+				t.Fatal(err)
+			}
+			if err := ValidateBreakdown(&f, tt.groupBy); !errors.Is(err, tt.err) {
+				t.Errorf("ValidateBreakdown error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestBreakdownSeries(t *testing.T) {
+	period := Period{Start: Month{Year: 2025, Month: 1}, End: Month{Year: 2025, Month: 3}}
+	b := NewBreakdown(period, DefaultCurrency, &Rates{}, ChargeActual)
+	for _, sub := range []*Subscription{
+		testSubscription(t, 40000, BillingMonthly, "12-2024", "02-2025"),
+		testSubscription(t, 19900, BillingMonthly, "02-2025", "02-2025"),
+	} {
+		if err := b.Add(sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := b.Series()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// месяцы без подписок тоже присутствуют в разбивке
+	want := []MonthTotal{
+		{Month: "01-2025", Total: 40000, Count: 1},
+		{Month: "02-2025", Total: 59900, Count: 2},
+		{Month: "03-2025", Total: 0, Count: 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Series() has %d months, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Series()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}